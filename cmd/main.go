@@ -12,9 +12,12 @@ import (
 	"housing-survey-api/config"
 	"housing-survey-api/controllers"
 	appcontext "housing-survey-api/internal/context"
+	"housing-survey-api/internal/jobs"
+	"housing-survey-api/internal/storage"
 	"housing-survey-api/models"
 	"housing-survey-api/routes"
 	"housing-survey-api/seed"
+	"housing-survey-api/services"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -39,9 +42,15 @@ func main() {
 		log.Println("🚫 Skipping migration & seeding on worker")
 	}
 
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("❌ Failed to initialize storage: %v", err)
+	}
+
 	appCtx := &appcontext.AppContext{
-		DB:     db,
-		Config: cfg,
+		DB:      db,
+		Config:  cfg,
+		Storage: store,
 	}
 
 	// Initialize services
@@ -50,13 +59,24 @@ func main() {
 	app := fiber.New(fiber.Config{
 		DisableDefaultDate:           true,
 		DisablePreParseMultipartForm: true,
+		// the server accepts upload sized bodies, smaller limits are enforced per route
+		BodyLimit: int(cfg.Storage.MaxUploadSize)*cfg.Storage.MaxUploadFiles + 1<<20,
 	})
 
 	// Setup middleware and routes
 	middleware.InitMiddleware(appCtx)
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, routes.LargeBodyPaths...))
 	routes.SetupRoutes(app, ctrl)
 	routes.PrintRoutes(app)
 
+	// Background jobs, each run by one instance at a time
+	scheduler := jobs.NewScheduler(db)
+	scheduler.Add(jobs.Job{Name: "survey-statistics", Interval: cfg.Statistics.RefreshInterval, Run: services.RefreshSurveyStatistics})
+	scheduler.Add(jobs.Job{Name: "survey-claims", Interval: cfg.Queue.ReleaseInterval, Run: services.ReleaseExpiredSurveyClaims})
+	scheduler.Add(jobs.Job{Name: "survey-sla", Interval: cfg.SLA.CheckInterval, Run: services.EscalateOverdueSurveys(cfg)})
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	scheduler.Start(jobsCtx)
+
 	// Graceful shutdown
 	go func() {
 		if err := app.Listen(":8080"); err != nil {
@@ -78,6 +98,10 @@ func main() {
 		log.Fatalf("❌ Server forced to shutdown: %v", err)
 	}
 
+	// Let running jobs finish before the database goes away
+	stopJobs()
+	scheduler.Wait()
+
 	// Insert audit log
 	insertShutdownLog(db)
 
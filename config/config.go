@@ -4,7 +4,9 @@ import (
 	"housing-survey-api/shared"
 	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +18,11 @@ type Config struct {
 	Token       string
 	AppRole     string
 	Resource    ResourceConfig
+	Storage     StorageConfig
+	Photo       PhotoConfig
+	Statistics  StatisticsConfig
+	Queue       QueueConfig
+	SLA         SLAConfig
 	BannedWords []string
 }
 
@@ -38,6 +45,20 @@ type RolesConfig struct {
 	Public             string
 }
 
+// WorkflowLevel maps a role name to the survey workflow level it may act on.
+// Roles outside the verification chain return an empty string.
+func (r RolesConfig) WorkflowLevel(role string) string {
+	switch role {
+	case r.Surveyor:
+		return shared.LevelSurveyor
+	case r.VerificatorBalai:
+		return shared.LevelBalai
+	case r.VerificatorEselon1:
+		return shared.LevelEselon1
+	}
+	return ""
+}
+
 type ResourceConfig struct {
 	TagNegara       string
 	TagPengembang   string
@@ -45,6 +66,54 @@ type ResourceConfig struct {
 	TagGotongRoyong string
 }
 
+type StorageConfig struct {
+	Driver         string // "local" or "s3"
+	LocalPath      string
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3PathStyle    bool  // required by most S3 stand-ins such as MinIO
+	MaxUploadSize  int64 // bytes per file
+	MaxUploadFiles int   // files per request
+}
+
+type PhotoConfig struct {
+	MaxDistanceMeters float64 // photo GPS further than this from the survey coordinate is flagged
+}
+
+type StatisticsConfig struct {
+	RefreshInterval time.Duration // how often changed surveys are folded into the statistics, 0 disables
+	// MaxAge is how stale the statistics may be before dashboards fall back to live queries
+	MaxAge time.Duration
+}
+
+type QueueConfig struct {
+	ClaimTTL time.Duration // how long a verifier's claim on a survey keeps others off it
+	// ReleaseInterval is how often expired claims are cleared, 0 disables the job
+	ReleaseInterval time.Duration
+}
+
+// SLAConfig is how long each level may take to verify a survey waiting on it
+type SLAConfig struct {
+	Balai   time.Duration // 0 disables the SLA of the level
+	Eselon1 time.Duration
+	// CheckInterval is how often overdue surveys are escalated, 0 disables the job
+	CheckInterval time.Duration
+}
+
+// For returns the SLA of a workflow level, 0 for levels without one
+func (c SLAConfig) For(level string) time.Duration {
+	switch level {
+	case shared.LevelBalai:
+		return c.Balai
+	case shared.LevelEselon1:
+		return c.Eselon1
+	}
+	return 0
+}
+
 func LoadConfig() *Config {
 	// Load .env if exists
 	if err := godotenv.Load(); err != nil {
@@ -77,6 +146,35 @@ func LoadConfig() *Config {
 		TagGotongRoyong: getEnv("RESOURCE_GOTONGROYONG", shared.TagGotongRoyong),
 	}
 
+	storageConfig := StorageConfig{
+		Driver:         getEnv("STORAGE_DRIVER", "local"),
+		LocalPath:      getEnv("STORAGE_LOCAL_PATH", "storage"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:    getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:    getEnv("S3_PATH_STYLE", "true") == "true",
+		MaxUploadSize:  int64(getEnvInt("UPLOAD_MAX_SIZE_MB", 5)) << 20,
+		MaxUploadFiles: getEnvInt("UPLOAD_MAX_FILES", 10),
+	}
+
+	statisticsConfig := StatisticsConfig{
+		RefreshInterval: time.Duration(getEnvInt("STATISTICS_REFRESH_SECONDS", 60)) * time.Second,
+		MaxAge:          time.Duration(getEnvInt("STATISTICS_MAX_AGE_SECONDS", 600)) * time.Second,
+	}
+
+	queueConfig := QueueConfig{
+		ClaimTTL:        time.Duration(getEnvInt("QUEUE_CLAIM_TTL_MINUTES", 30)) * time.Minute,
+		ReleaseInterval: time.Duration(getEnvInt("QUEUE_RELEASE_SECONDS", 60)) * time.Second,
+	}
+
+	slaConfig := SLAConfig{
+		Balai:         time.Duration(getEnvInt("SLA_BALAI_HOURS", 72)) * time.Hour,
+		Eselon1:       time.Duration(getEnvInt("SLA_ESELON_1_HOURS", 120)) * time.Hour,
+		CheckInterval: time.Duration(getEnvInt("SLA_CHECK_SECONDS", 300)) * time.Second,
+	}
+
 	bannedWordsList := []string{}
 	bannedWords := getEnv("BANNED_WORDS", "")
 	if bannedWords != "" {
@@ -90,6 +188,11 @@ func LoadConfig() *Config {
 		Token:       getEnv("JWT_SECRET", ""),
 		AppRole:     getEnv("APP_ROLE", "api"),
 		Resource:    resConfig,
+		Storage:     storageConfig,
+		Photo:       PhotoConfig{MaxDistanceMeters: float64(getEnvInt("PHOTO_MAX_DISTANCE_METERS", 500))},
+		Statistics:  statisticsConfig,
+		Queue:       queueConfig,
+		SLA:         slaConfig,
 		BannedWords: bannedWordsList,
 	}
 }
@@ -100,3 +203,11 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	val, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return val
+}
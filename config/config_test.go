@@ -0,0 +1,52 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"housing-survey-api/shared"
+)
+
+func TestWorkflowLevel(t *testing.T) {
+	roles := RolesConfig{
+		SuperAdmin:         "Super Admin",
+		AdminEselon1:       "Admin Eselon 1",
+		VerificatorEselon1: "Verificator Eselon 1",
+		AdminBalai:         "Admin Balai",
+		VerificatorBalai:   "Verificator Balai",
+		Surveyor:           "Surveyor",
+		Public:             "Public",
+	}
+	want := map[string]string{
+		"Surveyor":             shared.LevelSurveyor,
+		"Verificator Balai":    shared.LevelBalai,
+		"Verificator Eselon 1": shared.LevelEselon1,
+		"Admin Balai":          "",
+		"Super Admin":          "",
+		"Public":               "",
+		"":                     "",
+	}
+	for role, level := range want {
+		if got := roles.WorkflowLevel(role); got != level {
+			t.Errorf("WorkflowLevel(%q) = %q, want %q", role, got, level)
+		}
+	}
+}
+
+func TestSLAConfigFor(t *testing.T) {
+	sla := SLAConfig{Balai: 72 * time.Hour, Eselon1: 120 * time.Hour}
+	tests := []struct {
+		level string
+		want  time.Duration
+	}{
+		{shared.LevelBalai, 72 * time.Hour},
+		{shared.LevelEselon1, 120 * time.Hour},
+		{shared.LevelSurveyor, 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := sla.For(tt.level); got != tt.want {
+			t.Errorf("For(%q) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
@@ -20,6 +20,12 @@ type ControllerRegistry struct {
 	Role        *RoleController
 	Subdistrict *SubdistrictController
 	Village     *VillageController
+	Upload      *UploadController
+	Report      *ReportController
+	Target      *ProgramTargetController
+	Assignment  *SurveyAssignmentController
+	Notice      *NotificationController
+	Checklist   *ChecklistTemplateController
 }
 
 func InitControllers(appCtx *context.AppContext) *ControllerRegistry {
@@ -38,5 +44,11 @@ func InitControllers(appCtx *context.AppContext) *ControllerRegistry {
 		Role:        &RoleController{Service: services.NewRoleService(appCtx)},
 		Subdistrict: &SubdistrictController{Service: services.NewSubdistrictService(appCtx)},
 		Village:     &VillageController{Service: services.NewVillageService(appCtx)},
+		Upload:      &UploadController{Service: services.NewUploadService(appCtx)},
+		Report:      &ReportController{Service: services.NewReportService(appCtx)},
+		Target:      &ProgramTargetController{Service: services.NewProgramTargetService(appCtx)},
+		Assignment:  &SurveyAssignmentController{Service: services.NewSurveyAssignmentService(appCtx)},
+		Notice:      &NotificationController{Service: services.NewNotificationService(appCtx)},
+		Checklist:   &ChecklistTemplateController{Service: services.NewChecklistTemplateService(appCtx)},
 	}
 }
@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"housing-survey-api/services"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	Service services.NotificationService
+}
+
+func (c *NotificationController) GetMine(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetMine(ctx))
+}
+
+func (c *NotificationController) MarkRead(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.MarkRead(ctx, ctx.Params("id")))
+}
+
+func (c *NotificationController) MarkAllRead(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.MarkAllRead(ctx))
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"housing-survey-api/services"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportController struct {
+	Service services.ReportService
+}
+
+func (c *ReportController) GetMonthlyReport(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetMonthlyReport(ctx))
+}
+
+func (c *ReportController) GetBalaiFunnel(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetBalaiFunnel(ctx))
+}
+
+func (c *ReportController) GetTimeSeries(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetTimeSeries(ctx))
+}
+
+func (c *ReportController) GetSLAOverdue(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetSLAOverdue(ctx))
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+
+	"housing-survey-api/models"
+	"housing-survey-api/services"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SurveyAssignmentController struct {
+	Service services.SurveyAssignmentService
+}
+
+func (c *SurveyAssignmentController) GetAll(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetAll(ctx))
+}
+
+func (c *SurveyAssignmentController) GetMine(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.GetMine(ctx))
+}
+
+func (c *SurveyAssignmentController) GetByID(ctx *fiber.Ctx) error {
+	id := ctx.Params("id")
+	return utils.ToFiberJSON(ctx, c.Service.GetByID(ctx, id))
+}
+
+func (c *SurveyAssignmentController) Create(ctx *fiber.Ctx) error {
+	var input models.SurveyAssignmentInput
+	if err := ctx.BodyParser(&input); err != nil {
+		return utils.ToFiberJSON(ctx, models.ErrResponse(http.StatusBadRequest, "Invalid input"))
+	}
+	input.Mode = shared.Create
+	input.Actor = utils.GetActor(ctx)
+
+	return utils.ToFiberJSON(ctx, c.Service.Create(ctx, &input))
+}
+
+func (c *SurveyAssignmentController) Update(ctx *fiber.Ctx) error {
+	var input models.SurveyAssignmentInput
+	if err := ctx.BodyParser(&input); err != nil {
+		return utils.ToFiberJSON(ctx, models.ErrResponse(http.StatusBadRequest, "Invalid input"))
+	}
+	input.Mode = shared.Update
+	input.Actor = utils.GetActor(ctx)
+
+	return utils.ToFiberJSON(ctx, c.Service.Update(ctx, &input))
+}
+
+func (c *SurveyAssignmentController) Delete(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.Delete(ctx, ctx.Params("id")))
+}
+
+func (c *SurveyAssignmentController) Accept(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.Accept(ctx, ctx.Params("id")))
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"housing-survey-api/models"
 	"housing-survey-api/services"
@@ -19,6 +20,37 @@ type SurveyController struct {
 func (c *SurveyController) GetAllSurveys(ctx *fiber.Ctx) error {
 	return utils.ToFiberJSON(ctx, c.Survey.GetAllSurveys(ctx))
 }
+
+// ImportSurveys creates surveys from an uploaded CSV or XLSX file, or only checks it with dry_run=true
+func (c *SurveyController) ImportSurveys(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.ImportSurveys(ctx))
+}
+
+// ExportSurveys sends the filtered surveys as a CSV or XLSX file (format=csv|xlsx)
+func (c *SurveyController) ExportSurveys(ctx *fiber.Ctx) error {
+	format := ctx.Query("format", "csv")
+	write, res := c.Survey.ExportSurveys(ctx, format)
+	if write == nil {
+		return utils.ToFiberJSON(ctx, res)
+	}
+	ctx.Set(fiber.HeaderContentType, utils.SpreadsheetContentTypes[format])
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="surveys-%s.%s"`, time.Now().Format("20060102"), format))
+	ctx.Context().SetBodyStreamWriter(write)
+	return nil
+}
+
+// ExportGeoJSON streams the filtered surveys as a GeoJSON FeatureCollection
+func (c *SurveyController) ExportGeoJSON(ctx *fiber.Ctx) error {
+	write, res := c.Survey.ExportSurveysGeoJSON(ctx)
+	if write == nil {
+		return utils.ToFiberJSON(ctx, res)
+	}
+	ctx.Set(fiber.HeaderContentType, "application/geo+json")
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="surveys.geojson"`)
+	ctx.Context().SetBodyStreamWriter(write)
+	return nil
+}
+
 func (c *SurveyController) GetSurveyByID(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
 	return utils.ToFiberJSON(ctx, c.Survey.GetSurveyDetail(ctx, id))
@@ -66,6 +98,84 @@ func (c *SurveyController) ActionSurvey(ctx *fiber.Ctx) error {
 	return utils.ToFiberJSON(ctx, res)
 }
 
+// GetQueue lists the surveys waiting on the caller's verification, oldest first
+func (c *SurveyController) GetQueue(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.GetQueue(ctx))
+}
+
+// ClaimSurvey locks a queued survey for the caller for a limited time
+func (c *SurveyController) ClaimSurvey(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.ClaimSurvey(ctx, ctx.Params("id")))
+}
+
+// ReleaseSurvey gives a claimed survey back to the queue
+func (c *SurveyController) ReleaseSurvey(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.ReleaseSurvey(ctx, ctx.Params("id")))
+}
+
+func (c *SurveyController) ResubmitSurvey(ctx *fiber.Ctx) error {
+	var input models.SurveyResubmitInput
+	if len(ctx.Body()) > 0 {
+		if err := ctx.BodyParser(&input); err != nil {
+			fmt.Println("Error parsing request body:", err)
+			return utils.ToFiberJSON(ctx, models.ErrResponse(http.StatusBadRequest, "Invalid input"))
+		}
+	}
+	input.Actor = utils.GetActor(ctx)
+	return utils.ToFiberJSON(ctx, c.Survey.ResubmitSurvey(ctx, ctx.Params("id"), input))
+}
+
+// RequestCorrection handles changes to an already verified survey
+func (c *SurveyController) RequestCorrection(ctx *fiber.Ctx) error {
+	var input models.SurveyCorrectionInput
+	if err := ctx.BodyParser(&input); err != nil {
+		fmt.Println("Error parsing request body:", err)
+		return utils.ToFiberJSON(ctx, models.ErrResponse(http.StatusBadRequest, "Invalid input"))
+	}
+	input.Actor = utils.GetActor(ctx)
+	input.Survey.Actor = input.Actor
+	input.Survey.Mode = shared.Update
+	return utils.ToFiberJSON(ctx, c.Survey.RequestCorrection(ctx, ctx.Params("id"), input))
+}
+
+// ReturnSurvey handles Eselon 1 sending a survey back to the Balai for a recheck
+func (c *SurveyController) ReturnSurvey(ctx *fiber.Ctx) error {
+	var input models.SurveyReturnInput
+	if err := ctx.BodyParser(&input); err != nil {
+		fmt.Println("Error parsing request body:", err)
+		return utils.ToFiberJSON(ctx, models.ErrResponse(http.StatusBadRequest, "Invalid input"))
+	}
+	input.Actor = utils.GetActor(ctx)
+	return utils.ToFiberJSON(ctx, c.Survey.ReturnSurvey(ctx, ctx.Params("id"), input))
+}
+
+func (c *SurveyController) GetSurveyNotes(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.GetSurveyNotes(ctx, ctx.Params("id")))
+}
+
+// ReplySurveyNote handles a surveyor answering a verifier's note
+func (c *SurveyController) ReplySurveyNote(ctx *fiber.Ctx) error {
+	var input models.SurveyNoteReplyInput
+	if err := ctx.BodyParser(&input); err != nil {
+		fmt.Println("Error parsing request body:", err)
+		return utils.ToFiberJSON(ctx, models.ErrResponse(http.StatusBadRequest, "Invalid input"))
+	}
+	input.Actor = utils.GetActor(ctx)
+	return utils.ToFiberJSON(ctx, c.Survey.ReplySurveyNote(ctx, ctx.Params("id"), ctx.Params("note_id"), input))
+}
+
+func (c *SurveyController) GetSurveyTimeline(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.GetSurveyTimeline(ctx, ctx.Params("id")))
+}
+
+func (c *SurveyController) GetSurveyVersions(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.GetSurveyVersions(ctx, ctx.Params("id")))
+}
+
+func (c *SurveyController) DiffSurveyVersions(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.DiffSurveyVersions(ctx, ctx.Params("id"), ctx.Params("a"), ctx.Params("b")))
+}
+
 func (c *SurveyController) GetSurveysByResource(ctx *fiber.Ctx) error {
 	return utils.ToFiberJSON(ctx, c.Survey.GetSurveysByResource(ctx))
 }
@@ -77,3 +187,7 @@ func (c *SurveyController) GetSurveysByProgramType(ctx *fiber.Ctx) error {
 func (c *SurveyController) GetSurveysByVerificationStatus(ctx *fiber.Ctx) error {
 	return utils.ToFiberJSON(ctx, c.Survey.GetSurveysByVerificationStatus(ctx))
 }
+
+func (c *SurveyController) GetSurveysByStatus(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Survey.GetSurveysByStatus(ctx))
+}
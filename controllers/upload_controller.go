@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"housing-survey-api/services"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UploadController struct {
+	Service services.UploadService
+}
+
+func (c *UploadController) Upload(ctx *fiber.Ctx) error {
+	return utils.ToFiberJSON(ctx, c.Service.Upload(ctx))
+}
+
+func (c *UploadController) GetFile(ctx *fiber.Ctx) error {
+	upload, reader, res := c.Service.GetUploadFile(ctx, ctx.Params("id"), ctx.Query("size"))
+	if reader == nil {
+		return utils.ToFiberJSON(ctx, res)
+	}
+	ctx.Set(fiber.HeaderContentType, upload.MimeType)
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	return ctx.SendStream(reader, int(upload.Size))
+}
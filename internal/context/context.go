@@ -2,11 +2,13 @@ package context
 
 import (
 	"housing-survey-api/config"
+	"housing-survey-api/internal/storage"
 
 	"gorm.io/gorm"
 )
 
 type AppContext struct {
-	DB     *gorm.DB
-	Config *config.Config // your .env struct
+	DB      *gorm.DB
+	Config  *config.Config  // your .env struct
+	Storage storage.Storage // uploaded files
 }
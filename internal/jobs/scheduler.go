@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job is a task run periodically by one API instance at a time
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Run receives a connection holding the job's advisory lock
+	Run func(ctx context.Context, db *gorm.DB) error
+}
+
+// Scheduler runs jobs on their interval. Every instance behind the load balancer runs
+// a scheduler; a Postgres advisory lock per job makes sure only one of them runs it
+// at a time, the others skip that tick.
+type Scheduler struct {
+	db   *gorm.DB
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func NewScheduler(db *gorm.DB) *Scheduler {
+	return &Scheduler{db: db}
+}
+
+// Add registers a job, jobs without an interval are disabled
+func (s *Scheduler) Add(job Job) {
+	if job.Interval <= 0 {
+		log.Printf("⏸️  Job %s disabled", job.Name)
+		return
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job once right away and then on its interval until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				s.run(ctx, job)
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}(job)
+	}
+}
+
+// Wait blocks until the running jobs have returned after ctx is done
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	key := lockKey(job.Name)
+	// The lock belongs to a session, so take it, run and release it on one connection
+	err := s.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var locked bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil // another instance is running it
+		}
+		defer conn.Session(&gorm.Session{Context: context.Background()}).Exec("SELECT pg_advisory_unlock(?)", key)
+		return job.Run(ctx, conn)
+	})
+	if err != nil && ctx.Err() == nil {
+		log.Printf("⚠️ Job %s failed: %v", job.Name, err)
+	}
+}
+
+// lockKey derives the advisory lock key of a job from its name
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("housing-survey-api/jobs/" + name))
+	return int64(h.Sum64())
+}
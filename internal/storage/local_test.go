@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageKeys(t *testing.T) {
+	l, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", ".", "../outside.jpg", "a/../../outside.jpg", "/etc/passwd"} {
+		if err := l.Put(context.Background(), key, strings.NewReader("x"), 1, "image/jpeg"); err == nil {
+			t.Errorf("Put(%q) accepted a key outside the root", key)
+		}
+	}
+}
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	l, err := NewLocalStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	const key = "2024/05/photo.jpg"
+
+	if err := l.Put(ctx, key, strings.NewReader("jpeg bytes"), 10, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "2024", "05", "photo.jpg")); err != nil {
+		t.Fatalf("file not written under the root: %v", err)
+	}
+
+	r, err := l.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if string(got) != "jpeg bytes" {
+		t.Errorf("Get = %q", got)
+	}
+
+	if err := l.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := l.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing object = %v, want nil", err)
+	}
+}
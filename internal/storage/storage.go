@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"housing-survey-api/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores uploaded files by key, independent of where they are kept.
+type Storage interface {
+	// Name identifies the backend, saved with each upload so files can be found after a switch
+	Name() string
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the backend selected by STORAGE_DRIVER
+func New(cfg config.StorageConfig) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.LocalPath)
+	case "s3":
+		return NewS3Storage(cfg)
+	}
+	return nil, fmt.Errorf("unknown storage driver '%s'", cfg.Driver)
+}
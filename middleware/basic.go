@@ -47,6 +47,16 @@ func (m *Set) Surveyor() *Set {
 	return m.Custom(AuthRequired(), SurveyorOnly()).Basic()
 }
 
+// AdminBalai appends AuthRequired, AdminBalaiOnly and audit logger
+func (m *Set) AdminBalai() *Set {
+	return m.Custom(AuthRequired(), AdminBalaiOnly()).Basic()
+}
+
+// UploadBody appends the body limit of routes that receive files
+func (m *Set) UploadBody() *Set {
+	return m.Custom(BodyLimit(UploadBodyLimit()))
+}
+
 // Custom appends custom handlers
 func (m *Set) Custom(h ...fiber.Handler) *Set {
 	m.handlers = append(m.handlers, h...)
@@ -85,6 +95,11 @@ func SurveyorHandler(handler fiber.Handler) []fiber.Handler {
 	return With(handler, New().Surveyor().Build()...)
 }
 
+// AdminBalaiHandler applies Admin Balai middleware to the given handler
+func AdminBalaiHandler(handler fiber.Handler) []fiber.Handler {
+	return With(handler, New().AdminBalai().Build()...)
+}
+
 // CustomHandler lets you define an inline chain with a single handler
 func CustomHandler(handler fiber.Handler, m ...fiber.Handler) []fiber.Handler {
 	return With(handler, m...)
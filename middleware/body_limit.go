@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects requests whose Content-Length is above limit.
+// Requests to the paths in skip are left to the limit of their own route.
+func BodyLimit(limit int, skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Request().Header.ContentLength() <= limit {
+			return c.Next()
+		}
+		path := strings.TrimSuffix(c.Path(), "/")
+		for _, p := range skip {
+			if path == p {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Request body too large"})
+	}
+}
+
+// UploadBodyLimit is the body limit of routes that receive files:
+// the largest allowed upload request plus room for form overhead
+func UploadBodyLimit() int {
+	return int(appCtx.Config.Storage.MaxUploadSize)*appCtx.Config.Storage.MaxUploadFiles + 1<<20
+}
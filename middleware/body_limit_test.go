@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBodyLimit(t *testing.T) {
+	app := fiber.New()
+	app.Use(BodyLimit(4, "/upload"))
+	app.Post("/*", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	tests := []struct {
+		path string
+		body string
+		want int
+	}{
+		{"/survey", "1234", fiber.StatusOK},
+		{"/survey", "12345", fiber.StatusRequestEntityTooLarge},
+		{"/upload", "12345", fiber.StatusOK},
+		{"/upload/", "12345", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("POST %s with %d bytes = %d, want %d", tt.path, len(tt.body), resp.StatusCode, tt.want)
+		}
+	}
+}
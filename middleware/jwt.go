@@ -109,3 +109,23 @@ func SurveyorOnly() fiber.Handler {
 		return c.Next()
 	}
 }
+
+func AdminBalaiOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, err := utils.GetRoleNameFromContext(c)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to extract role")
+		}
+
+		// ✅ Use role names from appCtx
+		cfg := appCtx.Config
+		if role != cfg.Roles.AdminBalai {
+			utils.LogAudit(c, "FORBIDDEN", "Admin Balai access required")
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin Balai access required",
+			})
+		}
+
+		return c.Next()
+	}
+}
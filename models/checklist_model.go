@@ -0,0 +1,295 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"housing-survey-api/shared"
+
+	"gorm.io/gorm"
+)
+
+// ChecklistTemplate is the list of checks a verification level goes through for the surveys
+// of one program type. There is at most one template per program type and level.
+type ChecklistTemplate struct {
+	ID            uint `gorm:"primaryKey;autoIncrement"`
+	ProgramTypeID uint `gorm:"index;not null"`
+	ProgramType   ProgramType
+	Level         string          `gorm:"type:text;not null;index"` // Balai or Eselon 1
+	Name          string          `gorm:"type:text;not null"`
+	Items         []ChecklistItem `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE"`
+
+	CreatedBy string `gorm:"type:text"`
+	UpdatedBy string `gorm:"type:text"`
+	DeletedBy string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// ChecklistItem is one check of a template; a required item must be checked to approve
+type ChecklistItem struct {
+	ID         uint   `gorm:"primaryKey;autoIncrement"`
+	TemplateID uint   `gorm:"index;not null"`
+	Position   uint   `gorm:"not null"`
+	Label      string `gorm:"type:text;not null"`
+	Required   bool   `gorm:"default:false"`
+}
+
+func (t *ChecklistTemplate) UpdateFromInput(input *ChecklistTemplateInput) {
+	t.ProgramTypeID = input.ProgramTypeID
+	t.Level = input.Level
+	t.Name = input.Name
+	t.Items = input.toItems(true)
+	t.UpdatedBy = input.Actor
+	t.UpdatedAt = time.Now()
+}
+
+func (t *ChecklistTemplate) MarkDeleted(actor string) {
+	t.DeletedBy = actor
+	t.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+}
+
+func (t *ChecklistTemplate) ToResponse() ChecklistTemplateResponse {
+	items := make([]ChecklistItemResponse, len(t.Items))
+	for i, item := range t.Items {
+		items[i] = ChecklistItemResponse{ID: item.ID, Label: item.Label, Required: item.Required}
+	}
+	return ChecklistTemplateResponse{
+		ID:              t.ID,
+		ProgramTypeID:   t.ProgramTypeID,
+		ProgramTypeName: t.ProgramType.Name,
+		Level:           t.Level,
+		Name:            t.Name,
+		Items:           items,
+	}
+}
+
+func ToChecklistTemplateResponses(templates []ChecklistTemplate) []ChecklistTemplateResponse {
+	responses := make([]ChecklistTemplateResponse, len(templates))
+	for i, template := range templates {
+		responses[i] = template.ToResponse()
+	}
+	return responses
+}
+
+type ChecklistTemplateResponse struct {
+	ID              uint                    `json:"id"`
+	ProgramTypeID   uint                    `json:"program_type_id"`
+	ProgramTypeName string                  `json:"program_type_name"`
+	Level           string                  `json:"level"`
+	Name            string                  `json:"name"`
+	Items           []ChecklistItemResponse `json:"items"`
+}
+
+type ChecklistItemResponse struct {
+	ID       uint   `json:"id"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+type ChecklistTemplateInput struct {
+	ID            uint                 `json:"id"`
+	ProgramTypeID uint                 `json:"program_type_id" validate:"required"`
+	Level         string               `json:"level" validate:"required"`
+	Name          string               `json:"name" validate:"required"`
+	Items         []ChecklistItemInput `json:"items"`
+	Actor         string               `json:"-"` // created_by, updated_by
+	Mode          string               `json:"-"` // "create" or "update"
+}
+
+type ChecklistItemInput struct {
+	ID       uint   `json:"id"` // item to update, 0 for a new item
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+}
+
+func (i *ChecklistTemplateInput) Validate() error {
+	custom := map[string]string{
+		"ProgramTypeID.required": "Program type is required",
+		"Level.required":         "Level is required",
+		"Name.required":          "Checklist name is required",
+	}
+	if err := shared.CustomValidate(i, custom); err != nil {
+		return err
+	}
+	if i.Level != shared.LevelBalai && i.Level != shared.LevelEselon1 {
+		return errors.New("Level must be " + shared.LevelBalai + " or " + shared.LevelEselon1)
+	}
+	if len(i.Items) == 0 {
+		return errors.New("A checklist needs at least one item")
+	}
+	seen := make(map[uint]bool, len(i.Items))
+	for n := range i.Items {
+		i.Items[n].Label = strings.TrimSpace(i.Items[n].Label)
+		if i.Items[n].Label == "" {
+			return errors.New("Every checklist item needs a label")
+		}
+		if id := i.Items[n].ID; id != 0 {
+			if seen[id] {
+				return fmt.Errorf("Checklist item %d is listed twice", id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+// toItems numbers the items in the order they were sent. Updates keep the item IDs so
+// answers already given to an item stay linked to it.
+func (i *ChecklistTemplateInput) toItems(keepIDs bool) []ChecklistItem {
+	items := make([]ChecklistItem, len(i.Items))
+	for n, item := range i.Items {
+		items[n] = ChecklistItem{Position: uint(n + 1), Label: item.Label, Required: item.Required}
+		if keepIDs {
+			items[n].ID = item.ID
+		}
+	}
+	return items
+}
+
+func (i *ChecklistTemplateInput) ToModel() ChecklistTemplate {
+	now := time.Now()
+	return ChecklistTemplate{
+		ID:            i.ID,
+		ProgramTypeID: i.ProgramTypeID,
+		Level:         i.Level,
+		Name:          i.Name,
+		Items:         i.toItems(false),
+		CreatedBy:     i.Actor,
+		UpdatedBy:     i.Actor,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// SurveyChecklist is a checklist a verifier filled in when approving or rejecting a survey.
+// The items are copied from the template, so later template changes leave it as it was.
+type SurveyChecklist struct {
+	ID         uint                  `gorm:"primaryKey;autoIncrement"`
+	SurveyID   uint                  `gorm:"index;not null"`
+	TemplateID uint                  `gorm:"index"`
+	Name       string                `gorm:"type:text"`
+	Level      string                `gorm:"type:text;not null"`
+	Action     string                `gorm:"type:text;not null"`
+	Round      uint                  `gorm:"default:0"` // Survey.RevisionRound at the time of the action
+	ActorID    uint                  `gorm:"index"`
+	ActorEmail string                `gorm:"type:text"`
+	Items      []SurveyChecklistItem `gorm:"foreignKey:ChecklistID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time             `gorm:"index"`
+}
+
+type SurveyChecklistItem struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	ChecklistID uint   `gorm:"index;not null"`
+	ItemID      uint   // template item the answer is for
+	Position    uint   `gorm:"not null"`
+	Label       string `gorm:"type:text;not null"`
+	Required    bool
+	Checked     bool
+	Note        string `gorm:"type:text"`
+}
+
+// FillChecklist answers the items of a template. Items without an answer stay unchecked;
+// an answer to an item the template does not have, or a second answer to one, is an error.
+func FillChecklist(template *ChecklistTemplate, answers []ChecklistAnswerInput) ([]SurveyChecklistItem, error) {
+	known := make(map[uint]bool, len(template.Items))
+	for _, item := range template.Items {
+		known[item.ID] = true
+	}
+	byItem := make(map[uint]ChecklistAnswerInput, len(answers))
+	for _, a := range answers {
+		if !known[a.ItemID] {
+			return nil, fmt.Errorf("checklist item %d is not part of %s", a.ItemID, template.Name)
+		}
+		if _, ok := byItem[a.ItemID]; ok {
+			return nil, fmt.Errorf("checklist item %d is answered twice", a.ItemID)
+		}
+		byItem[a.ItemID] = a
+	}
+	items := make([]SurveyChecklistItem, len(template.Items))
+	for n, item := range template.Items {
+		answer := byItem[item.ID]
+		items[n] = SurveyChecklistItem{
+			ItemID:   item.ID,
+			Position: item.Position,
+			Label:    item.Label,
+			Required: item.Required,
+			Checked:  answer.Checked,
+			Note:     strings.TrimSpace(answer.Note),
+		}
+	}
+	return items, nil
+}
+
+// MissingRequired lists the labels of the required items left unchecked
+func (c *SurveyChecklist) MissingRequired() []string {
+	var missing []string
+	for _, item := range c.Items {
+		if item.Required && !item.Checked {
+			missing = append(missing, item.Label)
+		}
+	}
+	return missing
+}
+
+func (c *SurveyChecklist) ToResponse() SurveyChecklistResponse {
+	items := make([]SurveyChecklistItemResponse, len(c.Items))
+	for i, item := range c.Items {
+		items[i] = SurveyChecklistItemResponse{
+			ItemID:   item.ItemID,
+			Label:    item.Label,
+			Required: item.Required,
+			Checked:  item.Checked,
+			Note:     item.Note,
+		}
+	}
+	return SurveyChecklistResponse{
+		ID:         c.ID,
+		TemplateID: c.TemplateID,
+		Name:       c.Name,
+		Level:      c.Level,
+		Action:     c.Action,
+		Round:      c.Round,
+		ActorEmail: c.ActorEmail,
+		Items:      items,
+		CreatedAt:  c.CreatedAt,
+	}
+}
+
+func ToSurveyChecklistResponses(checklists []SurveyChecklist) []SurveyChecklistResponse {
+	responses := make([]SurveyChecklistResponse, len(checklists))
+	for i, checklist := range checklists {
+		responses[i] = checklist.ToResponse()
+	}
+	return responses
+}
+
+type SurveyChecklistResponse struct {
+	ID         uint                          `json:"id"`
+	TemplateID uint                          `json:"template_id"`
+	Name       string                        `json:"name"`
+	Level      string                        `json:"level"`
+	Action     string                        `json:"action"`
+	Round      uint                          `json:"round"`
+	ActorEmail string                        `json:"actor_email"`
+	Items      []SurveyChecklistItemResponse `json:"items"`
+	CreatedAt  time.Time                     `json:"created_at"`
+}
+
+type SurveyChecklistItemResponse struct {
+	ItemID   uint   `json:"item_id"`
+	Label    string `json:"label"`
+	Required bool   `json:"required"`
+	Checked  bool   `json:"checked"`
+	Note     string `json:"note,omitempty"`
+}
+
+// ChecklistAnswerInput answers one item of the checklist template of a survey
+type ChecklistAnswerInput struct {
+	ItemID  uint   `json:"item_id"`
+	Checked bool   `json:"checked"`
+	Note    string `json:"note"`
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFillChecklist(t *testing.T) {
+	template := &ChecklistTemplate{
+		Name: "Verifikasi Balai",
+		Items: []ChecklistItem{
+			{ID: 10, Position: 1, Label: "Foto sebelum", Required: true},
+			{ID: 11, Position: 2, Label: "Foto sesudah", Required: true},
+			{ID: 12, Position: 3, Label: "Catatan lapangan"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		answers []ChecklistAnswerInput
+		checked []bool   // per template item
+		notes   []string // per template item
+		missing []string
+		wantErr bool
+	}{
+		{
+			name:    "no answers",
+			checked: []bool{false, false, false},
+			notes:   []string{"", "", ""},
+			missing: []string{"Foto sebelum", "Foto sesudah"},
+		},
+		{
+			name: "required items checked",
+			answers: []ChecklistAnswerInput{
+				{ItemID: 11, Checked: true, Note: "  blur  "},
+				{ItemID: 10, Checked: true},
+			},
+			checked: []bool{true, true, false},
+			notes:   []string{"", "blur", ""},
+		},
+		{
+			name:    "one required item left",
+			answers: []ChecklistAnswerInput{{ItemID: 10, Checked: true}, {ItemID: 12, Checked: true}},
+			checked: []bool{true, false, true},
+			notes:   []string{"", "", ""},
+			missing: []string{"Foto sesudah"},
+		},
+		{
+			name:    "unknown item",
+			answers: []ChecklistAnswerInput{{ItemID: 10, Checked: true}, {ItemID: 99, Checked: true}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate item",
+			answers: []ChecklistAnswerInput{{ItemID: 10, Checked: true}, {ItemID: 10, Checked: false}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := FillChecklist(template, tt.answers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FillChecklist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			checked := make([]bool, len(items))
+			notes := make([]string, len(items))
+			for i, item := range items {
+				if item.ItemID != template.Items[i].ID || item.Label != template.Items[i].Label {
+					t.Fatalf("item %d = %+v, want a copy of %+v", i, item, template.Items[i])
+				}
+				checked[i], notes[i] = item.Checked, item.Note
+			}
+			if !reflect.DeepEqual(checked, tt.checked) {
+				t.Errorf("checked = %v, want %v", checked, tt.checked)
+			}
+			if !reflect.DeepEqual(notes, tt.notes) {
+				t.Errorf("notes = %q, want %q", notes, tt.notes)
+			}
+			checklist := SurveyChecklist{Items: items}
+			if missing := checklist.MissingRequired(); !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("MissingRequired() = %v, want %v", missing, tt.missing)
+			}
+		})
+	}
+}
@@ -124,7 +124,7 @@ type CommentInput struct {
 	Name       string   `json:"name" validate:"required"`
 	Detail     string   `json:"detail" validate:"required"`
 	IsResolved bool     `json:"is_resolved"`
-	Images     []string `json:"images"`
+	Images     []string `json:"images"` // upload IDs from POST /uploads
 	Actor      string   `json:"-"`
 	Mode       string   `json:"-"`
 }
@@ -1,19 +1,79 @@
 package models
 
 type DashboardResource struct {
-	Name  string `json:"name"`
-	Total int64  `json:"total"`
+	Name    string  `json:"name"`
+	Tag     string  `json:"tag"`
+	Total   int64   `json:"total"`
+	Percent float64 `json:"percent"`
+	Units   uint64  `json:"units"`
+	Budget  uint64  `json:"budget"`
 }
 
 type DashboardProgramType struct {
+	ID      uint    `json:"id"`
 	Name    string  `json:"name"`
 	Total   int     `json:"total"`
 	Percent float64 `json:"percent"`
+	Units   uint64  `json:"units"`
+	Budget  uint64  `json:"budget"`
 }
 
 type DashboardVerified struct {
-	Name          string  `json:"name"`
-	Total         int     `json:"total"`
-	VerifiedCount int     `json:"verified"`
-	Percent       float64 `json:"percent"`
+	Name           string  `json:"name"`
+	Total          int     `json:"total"`
+	VerifiedCount  int     `json:"verified"`
+	Percent        float64 `json:"percent"`
+	Units          uint64  `json:"units"`
+	VerifiedUnits  uint64  `json:"verified_units"`
+	Budget         uint64  `json:"budget"`
+	VerifiedBudget uint64  `json:"verified_budget"`
+}
+
+// DashboardGroupRow is one row of a grouped dashboard aggregate
+type DashboardGroupRow struct {
+	ID     uint
+	Name   string
+	Total  int64
+	Units  uint64
+	Budget uint64
+}
+
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+// StatusBreakdown counts surveys in every derived status, see Survey.GetStatusSurvey
+type StatusBreakdown struct {
+	Total  int64         `json:"total"`
+	Counts []StatusCount `json:"counts"`
+}
+
+type BalaiStatusBreakdown struct {
+	BalaiID   uint   `json:"balai_id"` // 0 for surveyors without a Balai
+	BalaiName string `json:"balai_name"`
+	StatusBreakdown
+}
+
+type MonthStatusBreakdown struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	StatusBreakdown
+}
+
+type DashboardStatus struct {
+	StatusBreakdown
+	ByBalai []BalaiStatusBreakdown `json:"by_balai"`
+	// ByMonth groups submitted surveys by month of first submission, drafts are left out
+	ByMonth []MonthStatusBreakdown `json:"by_month"`
+}
+
+// StatusGroupRow is a survey count of one status within a Balai or month
+type StatusGroupRow struct {
+	BalaiID   uint
+	BalaiName string
+	Year      int
+	Month     int
+	Status    string
+	Count     int64
 }
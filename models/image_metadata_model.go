@@ -0,0 +1,75 @@
+package models
+
+import "time"
+
+// ImageMetadata holds what was read from an uploaded photo when it was stored.
+type ImageMetadata struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	UploadID    string     `gorm:"type:uuid;uniqueIndex;not null"`
+	Width       int        `gorm:"not null"`
+	Height      int        `gorm:"not null"`
+	CapturedAt  *time.Time // EXIF DateTimeOriginal, nil when the camera did not record it
+	Latitude    *float64   // EXIF GPS position, nil when absent
+	Longitude   *float64
+	CameraMake  string `gorm:"type:text"`
+	CameraModel string `gorm:"type:text"`
+	CreatedAt   time.Time
+}
+
+func (ImageMetadata) TableName() string {
+	return "image_metadata"
+}
+
+// UploadThumbnail is a scaled down JPEG copy of an uploaded image.
+type UploadThumbnail struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UploadID  string `gorm:"type:uuid;uniqueIndex:idx_upload_thumbnails_upload_size;not null"`
+	Size      string `gorm:"type:text;uniqueIndex:idx_upload_thumbnails_upload_size;not null"` // key of shared.ThumbnailSizes
+	Key       string `gorm:"type:text;not null"`
+	Width     int    `gorm:"not null"`
+	Height    int    `gorm:"not null"`
+	FileSize  int64  `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func ThumbnailURL(uploadID, size string) string {
+	return UploadURL(uploadID) + "?size=" + size
+}
+
+// SurveyPhoto describes one image of a survey for verifiers.
+type SurveyPhoto struct {
+	UploadID   string            `json:"upload_id"`
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+	Width      int               `json:"width,omitempty"`
+	Height     int               `json:"height,omitempty"`
+	CapturedAt *time.Time        `json:"captured_at"`
+	Latitude   *float64          `json:"latitude"`
+	Longitude  *float64          `json:"longitude"`
+	// DistanceMeters is the distance between the photo GPS and the survey coordinate
+	DistanceMeters *float64 `json:"distance_meters"`
+	GPSMismatch    bool     `json:"gps_mismatch"`
+	// TakenBeforeYear is set when the photo was captured before the survey year
+	TakenBeforeYear bool `json:"taken_before_year"`
+}
+
+// NewSurveyPhoto builds the photo entry of an upload. metadata and thumbnails may be empty
+// for uploads stored before metadata extraction existed.
+func NewSurveyPhoto(uploadID string, metadata *ImageMetadata, thumbnails []UploadThumbnail) SurveyPhoto {
+	photo := SurveyPhoto{
+		UploadID:   uploadID,
+		URL:        UploadURL(uploadID),
+		Thumbnails: map[string]string{},
+	}
+	for _, t := range thumbnails {
+		photo.Thumbnails[t.Size] = ThumbnailURL(uploadID, t.Size)
+	}
+	if metadata != nil {
+		photo.Width = metadata.Width
+		photo.Height = metadata.Height
+		photo.CapturedAt = metadata.CapturedAt
+		photo.Latitude = metadata.Latitude
+		photo.Longitude = metadata.Longitude
+	}
+	return photo
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"log"
 
+	"housing-survey-api/shared"
+
 	"gorm.io/gorm"
 )
 
@@ -22,15 +24,42 @@ func MigrateAll(db *gorm.DB) error {
 			&Subdistrict{},
 			&Village{},
 			&Balai{},
+			&ProgramTarget{},
 			&User{},
 			&Profile{},
 			&Survey{},
+			&SurveyStatusHistory{},
+			&SurveyNote{},
+			&SurveyVersion{},
+			&SurveyAssignment{},
+			&ChecklistTemplate{},
+			&ChecklistItem{},
+			&SurveyChecklist{},
+			&SurveyChecklistItem{},
 			&Comment{},
+			&Upload{},
+			&ImageMetadata{},
+			&UploadThumbnail{},
+			&SurveyStatistic{},
+			&SurveyStatisticState{},
+			&Notification{},
 			&AuditLog{},
 		); err != nil {
 			return err
 		}
-		return nil
+		if err := indexProgramTargets(tx); err != nil {
+			return err
+		}
+		if err := backfillSurveyCoordinates(tx); err != nil {
+			return err
+		}
+		if err := backfillSurveySubmittedAt(tx); err != nil {
+			return err
+		}
+		if err := backfillSurveyStatusChangedAt(tx); err != nil {
+			return err
+		}
+		return backfillSurveyNotes(tx)
 	})
 
 	if err != nil {
@@ -41,3 +70,115 @@ func MigrateAll(db *gorm.DB) error {
 	log.Println("✅ Database migration complete.")
 	return nil
 }
+
+// ProgramTargetScopeIndex keeps one live target per program, year, province and Balai.
+// The national target has neither a province nor a Balai, hence the COALESCE.
+const ProgramTargetScopeIndex = "idx_program_targets_scope"
+
+func indexProgramTargets(tx *gorm.DB) error {
+	return tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS ` + ProgramTargetScopeIndex + ` ON program_targets
+		(program_id, year, COALESCE(province_id, 0), COALESCE(balai_id, 0)) WHERE deleted_at IS NULL`).Error
+}
+
+// backfillSurveyCoordinates parses the coordinate text of surveys saved before the
+// latitude and longitude columns existed. Coordinates that cannot be parsed stay empty.
+func backfillSurveyCoordinates(tx *gorm.DB) error {
+	var surveys []Survey
+	filled, skipped := 0, 0
+	err := tx.Select("id", "coordinate").
+		Where("latitude IS NULL AND coordinate IS NOT NULL AND coordinate <> ''").
+		FindInBatches(&surveys, 500, func(batch *gorm.DB, _ int) error {
+			for _, survey := range surveys {
+				survey.SetCoordinate(survey.Coordinate)
+				if survey.Latitude == nil {
+					skipped++
+					continue
+				}
+				if err := tx.Model(&Survey{}).Where("id = ?", survey.ID).
+					UpdateColumns(map[string]interface{}{"latitude": survey.Latitude, "longitude": survey.Longitude}).Error; err != nil {
+					return err
+				}
+				filled++
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+	if filled > 0 || skipped > 0 {
+		log.Printf("📍 Backfilled coordinates of %d surveys, %d could not be parsed", filled, skipped)
+	}
+	return nil
+}
+
+// backfillSurveySubmittedAt dates the first submission of surveys submitted before
+// submitted_at existed, from their status history or else their creation time.
+func backfillSurveySubmittedAt(tx *gorm.DB) error {
+	res := tx.Exec(`UPDATE surveys SET submitted_at = COALESCE(
+			(SELECT MIN(h.created_at) FROM survey_status_history h WHERE h.survey_id = surveys.id AND h.action = ?),
+			surveys.created_at)
+		WHERE surveys.submitted_at IS NULL AND surveys.is_submitted`, shared.ActionSubmit)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("📅 Backfilled submission date of %d surveys", res.RowsAffected)
+	}
+	return nil
+}
+
+// backfillSurveyStatusChangedAt dates the last transition of surveys changed before
+// status_changed_at existed, from their status history or else their submission or creation.
+func backfillSurveyStatusChangedAt(tx *gorm.DB) error {
+	res := tx.Exec(`UPDATE surveys SET status_changed_at = COALESCE(
+			(SELECT MAX(h.created_at) FROM survey_status_history h WHERE h.survey_id = surveys.id),
+			surveys.submitted_at, surveys.created_at)
+		WHERE surveys.status_changed_at IS NULL`)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("📅 Backfilled status change date of %d surveys", res.RowsAffected)
+	}
+	return nil
+}
+
+// backfillSurveyNotes turns the notes verifiers left before survey_notes existed into notes:
+// those in the status history, then the notes of rejected surveys that still have none,
+// at the level that rejected them.
+func backfillSurveyNotes(tx *gorm.DB) error {
+	levels := map[string]interface{}{
+		"waiting_balai":    shared.StatusWaitingBalai,
+		"waiting_eselon1":  shared.StatusWaitingEselon1,
+		"balai":            shared.LevelBalai,
+		"eselon1":          shared.LevelEselon1,
+		"rejected":         shared.Rejected,
+		"rejected_balai":   shared.StatusRejectedBalai,
+		"rejected_eselon1": shared.StatusRejectedEselon1,
+	}
+	fromHistory := tx.Exec(`INSERT INTO survey_notes
+			(survey_id, history_id, level, action, round, author_id, author_email, author_role, body, created_at)
+		SELECT h.survey_id, h.id, CASE h.from_status WHEN @waiting_balai THEN @balai ELSE @eselon1 END,
+			h.action, h.round, h.actor_id, h.actor_email, h.actor_role, h.notes, h.created_at
+		FROM survey_status_history h
+		WHERE h.notes <> '' AND h.from_status IN (@waiting_balai, @waiting_eselon1)
+			AND NOT EXISTS (SELECT 1 FROM survey_notes n WHERE n.history_id = h.id)`, levels)
+	if fromHistory.Error != nil {
+		return fromHistory.Error
+	}
+	fromSurveys := tx.Exec(`INSERT INTO survey_notes (survey_id, level, action, round, body, created_at)
+		SELECT surveys.id, CASE `+SurveyStatusSQL+` WHEN @rejected_balai THEN @balai ELSE @eselon1 END,
+			@rejected, surveys.revision_round, surveys.notes,
+			COALESCE(surveys.status_changed_at, surveys.updated_at, surveys.created_at)
+		FROM surveys
+		WHERE surveys.notes IS NOT NULL AND surveys.notes <> ''
+			AND `+SurveyStatusSQL+` IN (@rejected_balai, @rejected_eselon1)
+			AND NOT EXISTS (SELECT 1 FROM survey_notes n WHERE n.survey_id = surveys.id)`, levels)
+	if fromSurveys.Error != nil {
+		return fromSurveys.Error
+	}
+	if total := fromHistory.RowsAffected + fromSurveys.RowsAffected; total > 0 {
+		log.Printf("📝 Backfilled %d verification notes", total)
+	}
+	return nil
+}
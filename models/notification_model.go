@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	NotificationSLAOverdue = "SLA_OVERDUE" // survey waiting longer than its level's SLA
+	NotificationNoteReply  = "NOTE_REPLY"  // surveyor replied to a verification note
+)
+
+// Notification is a message for one user, shown in the application until read
+type Notification struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	UserID    uint   `gorm:"index;not null"`
+	Type      string `gorm:"type:text;not null;index"`
+	Title     string `gorm:"type:text;not null"`
+	Message   string `gorm:"type:text"`
+	SurveyID  *uint  `gorm:"index"` // survey the notification is about
+	ReadAt    *time.Time
+	CreatedAt time.Time `gorm:"index"`
+}
+
+type NotificationResponse struct {
+	ID        uint       `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	SurveyID  *uint      `json:"survey_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (n *Notification) ToResponse() NotificationResponse {
+	return NotificationResponse{
+		ID:        n.ID,
+		Type:      n.Type,
+		Title:     n.Title,
+		Message:   n.Message,
+		SurveyID:  n.SurveyID,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+func ToNotificationResponses(notifications []Notification) []NotificationResponse {
+	responses := make([]NotificationResponse, len(notifications))
+	for i, notification := range notifications {
+		responses[i] = notification.ToResponse()
+	}
+	return responses
+}
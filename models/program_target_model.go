@@ -0,0 +1,179 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"housing-survey-api/shared"
+
+	"gorm.io/gorm"
+)
+
+// ProgramTarget is the unit and budget target of a program for one fiscal year. Without
+// a province or Balai it is the national target; a target may instead be split per
+// province or per Balai, never both.
+type ProgramTarget struct {
+	ID           uint `gorm:"primaryKey;autoIncrement"`
+	ProgramID    uint `gorm:"index;not null"`
+	Program      Program
+	Year         uint  `gorm:"index;not null"`
+	ProvinceID   *uint `gorm:"index"`
+	Province     Province
+	BalaiID      *uint `gorm:"index"`
+	Balai        Balai
+	UnitTarget   uint64 `gorm:"not null"`
+	BudgetTarget uint64 `gorm:"not null"`
+
+	CreatedBy string `gorm:"type:text"`
+	UpdatedBy string `gorm:"type:text"`
+	DeletedBy string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (t *ProgramTarget) UpdateFromInput(input *ProgramTargetInput) {
+	t.ProgramID = input.ProgramID
+	t.Year = input.Year
+	t.ProvinceID = input.ProvinceID
+	t.BalaiID = input.BalaiID
+	t.UnitTarget = input.UnitTarget
+	t.BudgetTarget = input.BudgetTarget
+	t.UpdatedBy = input.Actor
+	t.UpdatedAt = time.Now()
+}
+
+func (t *ProgramTarget) MarkDeleted(actor string) {
+	t.DeletedBy = actor
+	t.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+}
+
+// Scope names what the target covers: national, province or balai
+func (t *ProgramTarget) Scope() string {
+	switch {
+	case t.ProvinceID != nil:
+		return TargetScopeProvince
+	case t.BalaiID != nil:
+		return TargetScopeBalai
+	}
+	return TargetScopeNational
+}
+
+const (
+	TargetScopeNational = "national"
+	TargetScopeProvince = "province"
+	TargetScopeBalai    = "balai"
+)
+
+func (t *ProgramTarget) ToResponse() ProgramTargetResponse {
+	return ProgramTargetResponse{
+		ID:           t.ID,
+		ProgramID:    t.ProgramID,
+		ProgramName:  t.Program.Name,
+		Year:         t.Year,
+		Scope:        t.Scope(),
+		ProvinceID:   t.ProvinceID,
+		ProvinceName: t.Province.Name,
+		BalaiID:      t.BalaiID,
+		BalaiName:    t.Balai.Name,
+		UnitTarget:   t.UnitTarget,
+		BudgetTarget: t.BudgetTarget,
+	}
+}
+
+func ToProgramTargetResponses(targets []ProgramTarget) []ProgramTargetResponse {
+	responses := make([]ProgramTargetResponse, len(targets))
+	for i, target := range targets {
+		responses[i] = target.ToResponse()
+	}
+	return responses
+}
+
+type ProgramTargetResponse struct {
+	ID           uint   `json:"id"`
+	ProgramID    uint   `json:"program_id"`
+	ProgramName  string `json:"program_name"`
+	Year         uint   `json:"year"`
+	Scope        string `json:"scope"` // national, province or balai
+	ProvinceID   *uint  `json:"province_id"`
+	ProvinceName string `json:"province_name,omitempty"`
+	BalaiID      *uint  `json:"balai_id"`
+	BalaiName    string `json:"balai_name,omitempty"`
+	UnitTarget   uint64 `json:"unit_target"`
+	BudgetTarget uint64 `json:"budget_target"`
+}
+
+type ProgramTargetInput struct {
+	ID           uint   `json:"id"`
+	ProgramID    uint   `json:"program_id" validate:"required"`
+	Year         uint   `json:"year" validate:"required,min=2000,max=2100"`
+	ProvinceID   *uint  `json:"province_id"`
+	BalaiID      *uint  `json:"balai_id"`
+	UnitTarget   uint64 `json:"unit_target"`
+	BudgetTarget uint64 `json:"budget_target"`
+	Actor        string `json:"-"` // created_by, updated_by
+	Mode         string `json:"-"` // "create" or "update"
+}
+
+func (t *ProgramTargetInput) Validate() error {
+	custom := map[string]string{
+		"ProgramID.required": "Program is required",
+		"Year.required":      "Year is required",
+		"Year.min":           "Year must be between 2000 and 2100",
+		"Year.max":           "Year must be between 2000 and 2100",
+	}
+	if err := shared.CustomValidate(t, custom); err != nil {
+		return err
+	}
+	// 0 is sent by forms for "none"
+	if t.ProvinceID != nil && *t.ProvinceID == 0 {
+		t.ProvinceID = nil
+	}
+	if t.BalaiID != nil && *t.BalaiID == 0 {
+		t.BalaiID = nil
+	}
+	if t.ProvinceID != nil && t.BalaiID != nil {
+		return errors.New("A target is split per province or per Balai, not both")
+	}
+	if t.UnitTarget == 0 && t.BudgetTarget == 0 {
+		return errors.New("Unit target or budget target is required")
+	}
+	return nil
+}
+
+func (t *ProgramTargetInput) ToModel() ProgramTarget {
+	now := time.Now()
+	return ProgramTarget{
+		ID:           t.ID,
+		ProgramID:    t.ProgramID,
+		Year:         t.Year,
+		ProvinceID:   t.ProvinceID,
+		BalaiID:      t.BalaiID,
+		UnitTarget:   t.UnitTarget,
+		BudgetTarget: t.BudgetTarget,
+		CreatedBy:    t.Actor,
+		UpdatedBy:    t.Actor,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// ProgramAchievement compares a target with the verified, realized surveys it covers
+type ProgramAchievement struct {
+	ProgramTargetResponse
+	Surveys        int64    `json:"surveys"`
+	RealizedUnits  uint64   `json:"realized_units"`
+	RealizedBudget uint64   `json:"realized_budget"`
+	UnitPercent    *float64 `json:"unit_percent"`   // nil without a unit target
+	BudgetPercent  *float64 `json:"budget_percent"` // nil without a budget target
+}
+
+// AchievementRow is the realization of one program within a province and Balai
+type AchievementRow struct {
+	ProgramID  uint
+	ProvinceID uint
+	BalaiID    uint
+	Surveys    int64 `gorm:"column:survey_count"`
+	Units      uint64
+	Budget     uint64
+}
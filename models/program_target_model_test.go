@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func uintPtr(v uint) *uint { return &v }
+
+func TestProgramTargetInputValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input ProgramTargetInput
+		ok    bool
+		scope string
+	}{
+		{name: "national", input: ProgramTargetInput{ProgramID: 1, Year: 2025, UnitTarget: 100}, ok: true, scope: TargetScopeNational},
+		{name: "province", input: ProgramTargetInput{ProgramID: 1, Year: 2025, ProvinceID: uintPtr(31), BudgetTarget: 5e9}, ok: true, scope: TargetScopeProvince},
+		{name: "balai", input: ProgramTargetInput{ProgramID: 1, Year: 2025, BalaiID: uintPtr(4), UnitTarget: 10}, ok: true, scope: TargetScopeBalai},
+		{name: "zero ids mean none", input: ProgramTargetInput{ProgramID: 1, Year: 2025, ProvinceID: uintPtr(0), BalaiID: uintPtr(0), UnitTarget: 1}, ok: true, scope: TargetScopeNational},
+		{name: "province and balai", input: ProgramTargetInput{ProgramID: 1, Year: 2025, ProvinceID: uintPtr(31), BalaiID: uintPtr(4), UnitTarget: 1}},
+		{name: "no target", input: ProgramTargetInput{ProgramID: 1, Year: 2025}},
+		{name: "no program", input: ProgramTargetInput{Year: 2025, UnitTarget: 1}},
+		{name: "year out of range", input: ProgramTargetInput{ProgramID: 1, Year: 1999, UnitTarget: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+			if (err == nil) != tt.ok {
+				t.Fatalf("Validate() = %v, want ok %v", err, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			target := tt.input.ToModel()
+			if got := target.Scope(); got != tt.scope {
+				t.Errorf("Scope() = %q, want %q", got, tt.scope)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"time"
+)
+
+// ReportBreakdown is the realization of one group (program type, resource tag, province, ...)
+type ReportBreakdown struct {
+	ID      uint   `json:"id,omitempty"`
+	Name    string `json:"name"`
+	Surveys int64  `json:"surveys"`
+	Units   uint64 `json:"units"`
+	Budget  uint64 `json:"budget"`
+}
+
+// MonthlyReportItem is the realization of one month with its breakdowns
+type MonthlyReportItem struct {
+	Year          int               `json:"year"`
+	Month         int               `json:"month"`
+	Surveys       int64             `json:"surveys"`
+	Units         uint64            `json:"units"`
+	Budget        uint64            `json:"budget"`
+	ByProgramType []ReportBreakdown `json:"by_program_type"`
+	ByResourceTag []ReportBreakdown `json:"by_resource_tag"`
+	ByProvince    []ReportBreakdown `json:"by_province"`
+}
+
+type MonthlyReport struct {
+	YearFrom     int                 `json:"year_from"`
+	YearTo       int                 `json:"year_to"`
+	VerifiedOnly bool                `json:"verified_only"`
+	Months       []MonthlyReportItem `json:"months"`
+	Total        ReportBreakdown     `json:"total"`
+}
+
+// ReportGroupRow is one grouped row scanned from a report query
+type ReportGroupRow struct {
+	Year    int
+	Month   int
+	ID      uint
+	Name    string
+	Surveys int64 `gorm:"column:survey_count"`
+	Units   uint64
+	Budget  uint64
+}
+
+func (r ReportGroupRow) ToBreakdown() ReportBreakdown {
+	return ReportBreakdown{ID: r.ID, Name: r.Name, Surveys: r.Surveys, Units: r.Units, Budget: r.Budget}
+}
+
+// FunnelStage is one derived survey status of the verification funnel
+type FunnelStage struct {
+	Status string `json:"status"`
+	Count  int64  `json:"count"` // surveys currently in this status
+	// AvgHours is the average time surveys spent in this status before leaving it,
+	// nil when no survey has left it yet
+	AvgHours *float64 `json:"avg_hours"`
+}
+
+// BalaiFunnel is the verification funnel of the surveys made by one Balai's surveyors
+type BalaiFunnel struct {
+	BalaiID        uint          `json:"balai_id"`
+	BalaiName      string        `json:"balai_name"`
+	Received       int64         `json:"received"` // submitted surveys, drafts left out
+	Rejected       int64         `json:"rejected"` // rejected by Balai or Eselon 1, waiting for resubmission
+	PendingEselon1 int64         `json:"pending_eselon1"`
+	Verified       int64         `json:"verified"`
+	Stages         []FunnelStage `json:"stages"`
+}
+
+// BalaiStatusRow is a survey count or stage duration of one Balai and status
+type BalaiStatusRow struct {
+	BalaiID  uint
+	Status   string
+	Count    int64
+	AvgHours float64
+}
+
+// TimeSeriesPoint counts the survey events of one bucket
+type TimeSeriesPoint struct {
+	Bucket    string `json:"bucket"` // first day of the week or month, YYYY-MM-DD
+	Created   int64  `json:"created"`
+	Submitted int64  `json:"submitted"` // submissions, resubmissions and corrections
+	Verified  int64  `json:"verified"`
+	Rejected  int64  `json:"rejected"` // rejections at either level
+}
+
+// TimeSeriesRow is one bucket scanned from a time-series query
+type TimeSeriesRow struct {
+	Bucket    string
+	Created   int64
+	Submitted int64
+	Verified  int64
+	Rejected  int64
+}
+
+// ProgramYearStat is the realization of a program in one survey year, compared with the year before
+type ProgramYearStat struct {
+	Year    int    `json:"year"`
+	Surveys int64  `json:"surveys"`
+	Units   uint64 `json:"units"`
+	Budget  uint64 `json:"budget"`
+	// Change against the previous year in percent, nil when the previous year had nothing
+	UnitsChange  *float64 `json:"units_change_percent"`
+	BudgetChange *float64 `json:"budget_change_percent"`
+}
+
+type ProgramYearOverYear struct {
+	ProgramID   uint              `json:"program_id"`
+	ProgramName string            `json:"program_name"`
+	Years       []ProgramYearStat `json:"years"`
+}
+
+type TimeSeriesAnalytics struct {
+	Bucket       string                `json:"bucket"` // week or month
+	From         string                `json:"from"`
+	To           string                `json:"to"`
+	Series       []TimeSeriesPoint     `json:"series"`
+	YearOverYear []ProgramYearOverYear `json:"year_over_year"`
+}
+
+// SLALevel is the verification SLA of one workflow level
+type SLALevel struct {
+	Level string  `json:"level"`
+	Hours float64 `json:"hours"`
+}
+
+// SLAOverdueRow is an overdue survey scanned from the SLA queries
+type SLAOverdueRow struct {
+	SurveyID      uint
+	SurveyName    string
+	Status        string
+	SurveyorEmail string
+	BalaiID       uint
+	BalaiName     string
+	WaitingSince  time.Time
+	SLABreachedAt *time.Time `gorm:"column:sla_breached_at"`
+}
+
+// SLAOverdueSurvey is a survey waiting on a verification level longer than its SLA
+type SLAOverdueSurvey struct {
+	SurveyID      uint       `json:"survey_id"`
+	SurveyName    string     `json:"survey_name"`
+	Status        string     `json:"status"`
+	Level         string     `json:"level"`
+	SurveyorEmail string     `json:"surveyor_email"`
+	BalaiID       uint       `json:"balai_id"`
+	BalaiName     string     `json:"balai_name"`
+	WaitingSince  time.Time  `json:"waiting_since"`
+	DueAt         time.Time  `json:"due_at"`
+	OverdueHours  float64    `json:"overdue_hours"`
+	EscalatedAt   *time.Time `json:"escalated_at"` // when admins were notified, nil until the next check
+}
+
+// SLAOverdueSummary counts the overdue surveys of one level and Balai
+type SLAOverdueSummary struct {
+	Level     string `json:"level"`
+	BalaiID   uint   `json:"balai_id"`
+	BalaiName string `json:"balai_name"`
+	Overdue   int64  `json:"overdue"`
+}
+
+// SLAOverdueSummaryRow is one group scanned from the overdue summary query
+type SLAOverdueSummaryRow struct {
+	Status    string
+	BalaiID   uint
+	BalaiName string
+	Overdue   int64
+}
@@ -1,10 +1,20 @@
 package models
 
+import "time"
+
 type ServiceResponse struct {
 	Status  bool        `json:"status"`         // true/false
 	Code    int         `json:"code"`           // HTTP code
 	Data    interface{} `json:"data,omitempty"` // can be object or list
 	Message string      `json:"message,omitempty"`
+	// RefreshedAt is when precomputed data in Data was last refreshed, absent for live data
+	RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+}
+
+// WithRefreshedAt sets the freshness of precomputed data, nil for live data
+func (r ServiceResponse) WithRefreshedAt(t *time.Time) ServiceResponse {
+	r.RefreshedAt = t
+	return r
 }
 
 // NewServiceResponse
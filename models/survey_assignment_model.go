@@ -0,0 +1,257 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"housing-survey-api/shared"
+
+	"gorm.io/gorm"
+)
+
+// SurveyAssignment is a survey an Admin Balai asks one of the Balai's surveyors to make:
+// a program at a location, due by a date. The surveyor accepts it and fulfils it by
+// creating the survey with its AssignmentID.
+type SurveyAssignment struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement"`
+	BalaiID       uint   `gorm:"index;not null"`
+	Balai         Balai  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+	Title         string `gorm:"type:text;not null"`
+	Notes         string `gorm:"type:text"`
+	ProgramID     uint   `gorm:"index;not null"`
+	Program       Program
+	Year          uint `gorm:"not null"`
+	ProvinceID    uint `gorm:"index;not null"`
+	Province      Province
+	DistrictID    uint `gorm:"index;not null"`
+	District      District
+	SubdistrictID *uint `gorm:"index"` // nil when any subdistrict of the district will do
+	Subdistrict   Subdistrict
+	VillageID     *uint `gorm:"index"` // nil when any village of the subdistrict will do
+	Village       Village
+	Address       string    `gorm:"type:text"`
+	DueDate       time.Time `gorm:"type:date;index;not null"`
+
+	AssigneeID  uint   `gorm:"index;not null"` // surveyor user
+	Assignee    User   `gorm:"foreignKey:AssigneeID"`
+	AssignedBy  uint   `gorm:"not null"` // Admin Balai user
+	Status      string `gorm:"type:text;not null;index"`
+	AcceptedAt  *time.Time
+	FulfilledAt *time.Time
+	SurveyID    *uint `gorm:"uniqueIndex"` // survey fulfilling the assignment
+
+	CreatedBy string `gorm:"type:text"`
+	UpdatedBy string `gorm:"type:text"`
+	DeletedBy string `gorm:"type:text"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// IsOpen reports whether the assignment still waits for its survey
+func (a *SurveyAssignment) IsOpen() bool {
+	return a.Status == shared.AssignmentAssigned || a.Status == shared.AssignmentAccepted
+}
+
+// DaysOverdue is the number of days an open assignment is past its due date, 0 otherwise
+func (a *SurveyAssignment) DaysOverdue(now time.Time) int {
+	if !a.IsOpen() {
+		return 0
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	due := time.Date(a.DueDate.Year(), a.DueDate.Month(), a.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+	if !today.After(due) {
+		return 0
+	}
+	return int(today.Sub(due).Hours() / 24)
+}
+
+// CheckFulfilment tells whether survey matches the program and location asked for
+func (a *SurveyAssignment) CheckFulfilment(survey *Survey) error {
+	switch {
+	case survey.ProgramID != a.ProgramID:
+		return errors.New("survey program does not match the assignment")
+	case survey.Year != a.Year:
+		return fmt.Errorf("survey year must be %d as assigned", a.Year)
+	case survey.ProvinceID != a.ProvinceID || survey.DistrictID != a.DistrictID,
+		a.SubdistrictID != nil && survey.SubdistrictID != *a.SubdistrictID,
+		a.VillageID != nil && survey.VillageID != *a.VillageID:
+		return errors.New("survey location does not match the assignment")
+	}
+	return nil
+}
+
+func (a *SurveyAssignment) UpdateFromInput(input *SurveyAssignmentInput) {
+	a.Title = input.Title
+	a.Notes = input.Notes
+	a.ProgramID = input.ProgramID
+	a.Year = input.Year
+	a.ProvinceID = input.ProvinceID
+	a.DistrictID = input.DistrictID
+	a.SubdistrictID = optionalID(input.SubdistrictID)
+	a.VillageID = optionalID(input.VillageID)
+	a.Address = input.Address
+	a.DueDate = input.dueDate
+	if a.AssigneeID != input.AssigneeID {
+		// A new surveyor has to accept it again
+		a.AssigneeID = input.AssigneeID
+		a.Status = shared.AssignmentAssigned
+		a.AcceptedAt = nil
+	}
+	a.UpdatedBy = input.Actor
+	a.UpdatedAt = time.Now()
+}
+
+// optionalID maps the 0 sent for "any" to nil
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+func (a *SurveyAssignment) MarkDeleted(actor string) {
+	a.DeletedBy = actor
+	a.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+}
+
+func (a *SurveyAssignment) ToResponse() SurveyAssignmentResponse {
+	daysOverdue := a.DaysOverdue(time.Now())
+	return SurveyAssignmentResponse{
+		ID:              a.ID,
+		BalaiID:         a.BalaiID,
+		BalaiName:       a.Balai.Name,
+		Title:           a.Title,
+		Notes:           a.Notes,
+		ProgramID:       a.ProgramID,
+		ProgramName:     a.Program.Name,
+		Year:            a.Year,
+		ProvinceID:      a.ProvinceID,
+		ProvinceName:    a.Province.Name,
+		DistrictID:      a.DistrictID,
+		DistrictName:    a.District.Name,
+		SubdistrictID:   a.SubdistrictID,
+		SubdistrictName: a.Subdistrict.Name,
+		VillageID:       a.VillageID,
+		VillageName:     a.Village.Name,
+		Address:         a.Address,
+		DueDate:         a.DueDate.Format("2006-01-02"),
+		AssigneeID:      a.AssigneeID,
+		AssigneeEmail:   a.Assignee.Email,
+		Status:          a.Status,
+		Overdue:         daysOverdue > 0,
+		DaysOverdue:     daysOverdue,
+		AcceptedAt:      a.AcceptedAt,
+		FulfilledAt:     a.FulfilledAt,
+		SurveyID:        a.SurveyID,
+		CreatedAt:       a.CreatedAt,
+	}
+}
+
+func ToSurveyAssignmentResponses(assignments []SurveyAssignment) []SurveyAssignmentResponse {
+	responses := make([]SurveyAssignmentResponse, len(assignments))
+	for i, assignment := range assignments {
+		responses[i] = assignment.ToResponse()
+	}
+	return responses
+}
+
+type SurveyAssignmentResponse struct {
+	ID              uint       `json:"id"`
+	BalaiID         uint       `json:"balai_id"`
+	BalaiName       string     `json:"balai_name"`
+	Title           string     `json:"title"`
+	Notes           string     `json:"notes"`
+	ProgramID       uint       `json:"program_id"`
+	ProgramName     string     `json:"program_name"`
+	Year            uint       `json:"year"`
+	ProvinceID      uint       `json:"province_id"`
+	ProvinceName    string     `json:"province_name"`
+	DistrictID      uint       `json:"district_id"`
+	DistrictName    string     `json:"district_name"`
+	SubdistrictID   *uint      `json:"subdistrict_id"`
+	SubdistrictName string     `json:"subdistrict_name"`
+	VillageID       *uint      `json:"village_id"`
+	VillageName     string     `json:"village_name"`
+	Address         string     `json:"address"`
+	DueDate         string     `json:"due_date"` // YYYY-MM-DD
+	AssigneeID      uint       `json:"assignee_id"`
+	AssigneeEmail   string     `json:"assignee_email"`
+	Status          string     `json:"status"`
+	Overdue         bool       `json:"overdue"`
+	DaysOverdue     int        `json:"days_overdue"`
+	AcceptedAt      *time.Time `json:"accepted_at"`
+	FulfilledAt     *time.Time `json:"fulfilled_at"`
+	SurveyID        *uint      `json:"survey_id"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+type SurveyAssignmentInput struct {
+	ID            uint   `json:"id" validate:"required_if=Mode update"`
+	Title         string `json:"title" validate:"required"`
+	Notes         string `json:"notes"`
+	ProgramID     uint   `json:"program_id" validate:"required"`
+	Year          uint   `json:"year" validate:"required"`
+	ProvinceID    uint   `json:"province_id" validate:"required"`
+	DistrictID    uint   `json:"district_id" validate:"required"`
+	SubdistrictID uint   `json:"subdistrict_id"` // 0 for any subdistrict of the district
+	VillageID     uint   `json:"village_id"`     // 0 for any village of the subdistrict
+	Address       string `json:"address"`
+	DueDate       string `json:"due_date" validate:"required"` // YYYY-MM-DD
+	AssigneeID    uint   `json:"assignee_id" validate:"required"`
+	Actor         string `json:"-"` // created_by, updated_by
+	Mode          string `json:"-"` // "create" or "update"
+
+	dueDate time.Time
+}
+
+func (a *SurveyAssignmentInput) Validate() error {
+	custom := map[string]string{
+		"ID.required_if":      "Assignment ID is required",
+		"Title.required":      "Title is required",
+		"ProgramID.required":  "Program is required",
+		"Year.required":       "Year is required",
+		"ProvinceID.required": "Province is required",
+		"DistrictID.required": "District is required",
+		"DueDate.required":    "Due date is required",
+		"AssigneeID.required": "Surveyor is required",
+	}
+	if err := shared.CustomValidate(a, custom); err != nil {
+		return err
+	}
+	due, err := time.Parse("2006-01-02", a.DueDate)
+	if err != nil {
+		return errors.New("Due date must be formatted as YYYY-MM-DD")
+	}
+	if a.VillageID != 0 && a.SubdistrictID == 0 {
+		return errors.New("Subdistrict is required when a village is set")
+	}
+	a.dueDate = due
+	return nil
+}
+
+// ToModel builds a new assignment; Validate must have succeeded
+func (a *SurveyAssignmentInput) ToModel(balaiID, assignedBy uint) SurveyAssignment {
+	now := time.Now()
+	return SurveyAssignment{
+		BalaiID:       balaiID,
+		Title:         a.Title,
+		Notes:         a.Notes,
+		ProgramID:     a.ProgramID,
+		Year:          a.Year,
+		ProvinceID:    a.ProvinceID,
+		DistrictID:    a.DistrictID,
+		SubdistrictID: optionalID(a.SubdistrictID),
+		VillageID:     optionalID(a.VillageID),
+		Address:       a.Address,
+		DueDate:       a.dueDate,
+		AssigneeID:    a.AssigneeID,
+		AssignedBy:    assignedBy,
+		Status:        shared.AssignmentAssigned,
+		CreatedBy:     a.Actor,
+		UpdatedBy:     a.Actor,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"housing-survey-api/shared"
+)
+
+func TestSurveyAssignmentDaysOverdue(t *testing.T) {
+	due := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	a := SurveyAssignment{DueDate: due, Status: shared.AssignmentAccepted}
+
+	// Late in the evening in Jakarta of the due date is not overdue yet
+	wib := time.FixedZone("WIB", 7*3600)
+	if got := a.DaysOverdue(time.Date(2025, 3, 10, 23, 30, 0, 0, wib)); got != 0 {
+		t.Errorf("on the due date: %d days overdue", got)
+	}
+	if got := a.DaysOverdue(time.Date(2025, 3, 11, 0, 5, 0, 0, wib)); got != 1 {
+		t.Errorf("day after: %d days overdue, want 1", got)
+	}
+	if got := a.DaysOverdue(time.Date(2025, 4, 9, 12, 0, 0, 0, wib)); got != 30 {
+		t.Errorf("a month later: %d days overdue, want 30", got)
+	}
+
+	a.Status = shared.AssignmentFulfilled
+	if got := a.DaysOverdue(time.Date(2025, 4, 9, 12, 0, 0, 0, wib)); got != 0 {
+		t.Errorf("fulfilled assignment: %d days overdue", got)
+	}
+}
+
+func TestSurveyAssignmentCheckFulfilment(t *testing.T) {
+	subdistrict := uint(30)
+	a := SurveyAssignment{ProgramID: 1, Year: 2025, ProvinceID: 10, DistrictID: 20, SubdistrictID: &subdistrict}
+	match := Survey{ProgramID: 1, Year: 2025, ProvinceID: 10, DistrictID: 20, SubdistrictID: 30, VillageID: 41}
+	if err := a.CheckFulfilment(&match); err != nil {
+		t.Fatalf("matching survey refused: %v", err)
+	}
+
+	mismatches := map[string]func(s *Survey){
+		"program":     func(s *Survey) { s.ProgramID = 2 },
+		"year":        func(s *Survey) { s.Year = 2024 },
+		"district":    func(s *Survey) { s.DistrictID = 21 },
+		"subdistrict": func(s *Survey) { s.SubdistrictID = 31 },
+	}
+	for name, change := range mismatches {
+		s := match
+		change(&s)
+		if err := a.CheckFulfilment(&s); err == nil {
+			t.Errorf("survey with another %s accepted", name)
+		}
+	}
+}
+
+func TestSurveyAssignmentReassign(t *testing.T) {
+	accepted := time.Now()
+	a := SurveyAssignment{AssigneeID: 5, Status: shared.AssignmentAccepted, AcceptedAt: &accepted}
+
+	input := SurveyAssignmentInput{AssigneeID: 5, Title: "Renamed"}
+	a.UpdateFromInput(&input)
+	if a.Status != shared.AssignmentAccepted || a.AcceptedAt == nil {
+		t.Errorf("same assignee lost acceptance: %s %v", a.Status, a.AcceptedAt)
+	}
+
+	input.AssigneeID = 6
+	a.UpdateFromInput(&input)
+	if a.Status != shared.AssignmentAssigned || a.AcceptedAt != nil {
+		t.Errorf("new assignee kept acceptance: %s %v", a.Status, a.AcceptedAt)
+	}
+}
+
+func TestSurveyAssignmentInputValidate(t *testing.T) {
+	valid := SurveyAssignmentInput{
+		Title: "RSH Desa Sukamaju", ProgramID: 1, Year: 2025, ProvinceID: 10, DistrictID: 20,
+		DueDate: "2025-06-30", AssigneeID: 5, Mode: shared.Create,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid input refused: %v", err)
+	}
+	if m := valid.ToModel(3, 9); !m.DueDate.Equal(time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)) || m.SubdistrictID != nil {
+		t.Errorf("ToModel due %v subdistrict %v", m.DueDate, m.SubdistrictID)
+	}
+
+	badDate := valid
+	badDate.DueDate = "30/06/2025"
+	if err := badDate.Validate(); err == nil {
+		t.Error("due date in another format accepted")
+	}
+	villageOnly := valid
+	villageOnly.VillageID = 41
+	if err := villageOnly.Validate(); err == nil {
+		t.Error("village without subdistrict accepted")
+	}
+}
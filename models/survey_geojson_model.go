@@ -0,0 +1,70 @@
+package models
+
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // [lng, lat]
+}
+
+type SurveyFeatureProperties struct {
+	ID                uint   `json:"id"`
+	Name              string `json:"survey_name"`
+	Address           string `json:"address"`
+	Type              string `json:"type"`
+	MbrStatus         string `json:"mbr_status"`
+	Year              uint   `json:"year"`
+	UnitTarget        uint   `json:"unit_target"`
+	StatusRealization string `json:"status_realization"`
+	YearRealization   uint   `json:"year_realization"`
+	MonthRealization  uint   `json:"month_realization"`
+	ProgramTypeName   string `json:"program_type_name"`
+	ResourceName      string `json:"resource_name"`
+	ProgramName       string `json:"program_name"`
+	Budget            uint64 `json:"budget"`
+	Status            string `json:"status"`
+	StatusBalai       string `json:"status_balai"`
+	StatusEselon1     string `json:"status_eselon1"`
+	ProvinceName      string `json:"province_name"`
+	DistrictName      string `json:"district_name"`
+	SubdistrictName   string `json:"subdistrict_name"`
+	VillageName       string `json:"village_name"`
+}
+
+type SurveyFeature struct {
+	Type       string                  `json:"type"`
+	Geometry   GeoJSONPoint            `json:"geometry"`
+	Properties SurveyFeatureProperties `json:"properties"`
+}
+
+// ToFeature builds the GeoJSON feature of a row, which must have a coordinate
+func (r *SurveyRow) ToFeature() SurveyFeature {
+	return SurveyFeature{
+		Type: "Feature",
+		Geometry: GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: [2]float64{*r.Longitude, *r.Latitude},
+		},
+		Properties: SurveyFeatureProperties{
+			ID:                r.ID,
+			Name:              r.Name,
+			Address:           r.Address,
+			Type:              r.Type,
+			MbrStatus:         r.MbrStatus,
+			Year:              r.Year,
+			UnitTarget:        r.UnitTarget,
+			StatusRealization: r.StatusRealization,
+			YearRealization:   r.YearRealization,
+			MonthRealization:  r.MonthRealization,
+			ProgramTypeName:   r.ProgramTypeName,
+			ResourceName:      r.ResourceName,
+			ProgramName:       r.ProgramName,
+			Budget:            r.Budget,
+			Status:            r.Status(),
+			StatusBalai:       r.StatusBalai,
+			StatusEselon1:     r.StatusEselon1,
+			ProvinceName:      r.ProvinceName,
+			DistrictName:      r.DistrictName,
+			SubdistrictName:   r.SubdistrictName,
+			VillageName:       r.VillageName,
+		},
+	}
+}
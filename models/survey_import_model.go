@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SurveyImportColumns are the spreadsheet headers a survey import accepts. Headers are
+// matched case-insensitively with spaces read as underscores. Program and region columns
+// hold names, resolved to IDs during the import.
+var SurveyImportColumns = []string{
+	"name", "address", "type", "mbr_status", "year", "unit_target",
+	"status_realization", "year_realization", "month_realization",
+	"program_type", "resource", "program", "budget", "coordinate",
+	"province", "district", "subdistrict", "village", "is_submitted",
+}
+
+// SurveyImportRequiredColumns must be present in the header row
+var SurveyImportRequiredColumns = []string{
+	"name", "address", "type", "mbr_status", "year", "unit_target", "status_realization",
+	"program_type", "resource", "program", "province", "district", "subdistrict", "village",
+}
+
+// NormalizeImportHeader turns a spreadsheet header such as "MBR Status" into "mbr_status"
+func NormalizeImportHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
+
+// SurveyImportRow is one spreadsheet row being imported.
+type SurveyImportRow struct {
+	Line   int               // spreadsheet line number, the header is line 1
+	Values map[string]string // cell values by normalized header
+	Input  SurveyInput
+	Errors []string
+}
+
+// ToSurveyInput fills the plain fields of the row input. Names are resolved by the caller.
+func (r *SurveyImportRow) ToSurveyInput() {
+	get := func(column string) string { return strings.TrimSpace(r.Values[column]) }
+	number := func(column string) uint64 {
+		value := get(column)
+		if value == "" {
+			return 0
+		}
+		n, err := parseImportNumber(value)
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("%s must be a whole number, got '%s'", column, value))
+		}
+		return n
+	}
+
+	r.Input.Name = get("name")
+	r.Input.Address = get("address")
+	r.Input.Type = get("type")
+	r.Input.MbrStatus = get("mbr_status")
+	r.Input.Year = uint(number("year"))
+	r.Input.UnitTarget = uint(number("unit_target"))
+	r.Input.StatusRealization = get("status_realization")
+	r.Input.YearRealization = uint(number("year_realization"))
+	r.Input.MonthRealization = uint(number("month_realization"))
+	r.Input.Budget = number("budget")
+	r.Input.Coordinate = get("coordinate")
+	switch strings.ToLower(get("is_submitted")) {
+	case "", "false", "no", "tidak", "0":
+	case "true", "yes", "ya", "1":
+		r.Input.IsSubmitted = true
+	default:
+		r.Errors = append(r.Errors, fmt.Sprintf("is_submitted must be true or false, got '%s'", get("is_submitted")))
+	}
+}
+
+// parseImportNumber reads a non-negative whole number. XLSX stores every number as a float.
+func parseImportNumber(value string) (uint64, error) {
+	if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || f != math.Trunc(f) || f > math.MaxInt64 {
+		return 0, strconv.ErrSyntax
+	}
+	return uint64(f), nil
+}
+
+type SurveyImportRowResult struct {
+	Line     int      `json:"line"`
+	Name     string   `json:"name"`
+	Valid    bool     `json:"valid"`
+	SurveyID uint     `json:"survey_id,omitempty"` // set once the row is imported
+	Errors   []string `json:"errors,omitempty"`
+}
+
+type SurveyImportResult struct {
+	DryRun      bool                    `json:"dry_run"`
+	TotalRows   int                     `json:"total_rows"`
+	ValidRows   int                     `json:"valid_rows"`
+	InvalidRows int                     `json:"invalid_rows"`
+	Imported    int                     `json:"imported"`
+	Rows        []SurveyImportRowResult `json:"rows"`
+}
+
+func ToSurveyImportResult(rows []SurveyImportRow, dryRun bool) SurveyImportResult {
+	result := SurveyImportResult{DryRun: dryRun, TotalRows: len(rows), Rows: make([]SurveyImportRowResult, len(rows))}
+	for i, r := range rows {
+		valid := len(r.Errors) == 0
+		if valid {
+			result.ValidRows++
+		} else {
+			result.InvalidRows++
+		}
+		result.Rows[i] = SurveyImportRowResult{Line: r.Line, Name: r.Input.Name, Valid: valid, Errors: r.Errors}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestParseImportNumber(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{in: "2024", want: 2024},
+		{in: "0", want: 0},
+		{in: "150000000", want: 150000000},
+		{in: "2024.0", want: 2024},
+		{in: "1.5E+08", want: 150000000},
+		{in: "12.5", wantErr: true},
+		{in: "-3", wantErr: true},
+		{in: "1.000.000", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseImportNumber(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseImportNumber(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseImportNumber(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSurveyImportRowToSurveyInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		values     map[string]string
+		check      func(in SurveyInput) bool
+		wantErrors int
+	}{
+		{
+			name: "plain fields",
+			values: map[string]string{
+				"name": " Rumah A ", "year": "2024", "unit_target": "10.0", "budget": "150000000",
+				"coordinate": "-6.2,106.8",
+			},
+			check: func(in SurveyInput) bool {
+				return in.Name == "Rumah A" && in.Year == 2024 && in.UnitTarget == 10 &&
+					in.Budget == 150000000 && in.Coordinate == "-6.2,106.8" && !in.IsSubmitted
+			},
+		},
+		{
+			name:   "empty numbers stay zero",
+			values: map[string]string{"year_realization": "", "month_realization": " "},
+			check:  func(in SurveyInput) bool { return in.YearRealization == 0 && in.MonthRealization == 0 },
+		},
+		{
+			name:   "submitted in Indonesian",
+			values: map[string]string{"is_submitted": "Ya"},
+			check:  func(in SurveyInput) bool { return in.IsSubmitted },
+		},
+		{
+			name:       "bad number",
+			values:     map[string]string{"year": "dua ribu", "unit_target": "1.5"},
+			check:      func(in SurveyInput) bool { return in.Year == 0 },
+			wantErrors: 2,
+		},
+		{
+			name:       "bad is_submitted",
+			values:     map[string]string{"is_submitted": "maybe"},
+			check:      func(in SurveyInput) bool { return !in.IsSubmitted },
+			wantErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := SurveyImportRow{Line: 2, Values: tt.values}
+			row.ToSurveyInput()
+			if len(row.Errors) != tt.wantErrors {
+				t.Errorf("errors = %v, want %d", row.Errors, tt.wantErrors)
+			}
+			if !tt.check(row.Input) {
+				t.Errorf("unexpected input %+v", row.Input)
+			}
+		})
+	}
+}
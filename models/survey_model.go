@@ -1,6 +1,10 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"housing-survey-api/shared"
@@ -28,10 +32,21 @@ type Survey struct {
 	ProgramID         uint           `gorm:"index"`
 	Budget            uint64         // jumlah anggaran
 	Coordinate        string         `gorm:"type:text"`
+	Latitude          *float64       `gorm:"index:idx_surveys_lat_lng"` // parsed from Coordinate, nil when empty
+	Longitude         *float64       `gorm:"index:idx_surveys_lat_lng"`
 	StatusBalai       string         `gorm:"type:text;default:'Pending';check:status_balai IN ('Pending', 'Approved', 'Rejected')"` // Pending, Approved, Rejected
 	StatusEselon1     string         `gorm:"type:text;default:'Pending';check:status_balai IN ('Pending', 'Approved', 'Rejected')"` // Pending, Approved, Rejected
 	IsSubmitted       bool           `gorm:"default:false"`
-	Notes             string         `gorm:"type:text"` // Notes for Balai or Eselon1
+	RevisionRound     uint           `gorm:"default:0"` // number of resubmissions after rejection
+	SubmittedAt       *time.Time     `gorm:"index"`     // first submission for verification
+	StatusChangedAt   *time.Time     `gorm:"index"`     // last workflow transition, the queue is oldest first
+	ClaimedBy         *uint          `gorm:"index"`     // verifier working on the pending survey
+	ClaimedAt         *time.Time     // start of the claim
+	ClaimExpiresAt    *time.Time     `gorm:"index"`     // others may take the survey after this
+	SLABreachedAt     *time.Time     `gorm:"index"`     // escalated as overdue in its current waiting state
+	ReturnedAt        *time.Time     `gorm:"index"`     // returned by Eselon 1, until the Balai acts on it again
+	ReturnNotes       string         `gorm:"type:text"` // what Eselon 1 asked the Balai to recheck
+	Notes             string         `gorm:"type:text"` // Notes of the current rejection, see SurveyNote
 	ImagesBefore      pq.StringArray `gorm:"type:text[]"`
 	ImagesAfter       pq.StringArray `gorm:"type:text[]"`
 	ProvinceID        uint           `gorm:"index"`
@@ -76,13 +91,25 @@ type SurveyResponse struct {
 	ProgramName       string         `json:"program_name"`
 	Budget            uint64         `json:"budget"`
 	Coordinate        string         `json:"coordinate"` // lat,lng string or GeoJSON
+	Latitude          *float64       `json:"latitude"`
+	Longitude         *float64       `json:"longitude"`
 	Status            string         `json:"status"`
 	StatusBalai       string         `json:"status_balai"`
 	StatusEselon1     string         `json:"status_eselon1"`
 	IsSubmitted       bool           `json:"is_submitted"` // default false
+	RevisionRound     uint           `json:"revision_round"`
+	SubmittedAt       *time.Time     `json:"submitted_at"`
+	StatusChangedAt   *time.Time     `json:"status_changed_at"`
+	ClaimedBy         *uint          `json:"claimed_by"`
+	ClaimExpiresAt    *time.Time     `json:"claim_expires_at"`
+	SLABreachedAt     *time.Time     `json:"sla_breached_at"`
+	ReturnedAt        *time.Time     `json:"returned_at"`
+	ReturnNotes       string         `json:"return_notes"`
 	Notes             string         `json:"notes"`
 	ImagesBefore      pq.StringArray `json:"images_before"`
 	ImagesAfter       pq.StringArray `json:"images_after"`
+	PhotosBefore      []SurveyPhoto  `json:"photos_before,omitempty"` // only filled in the survey detail
+	PhotosAfter       []SurveyPhoto  `json:"photos_after,omitempty"`
 	ProvinceID        uint           `json:"province_id"`
 	ProvinceName      string         `json:"province_name"`
 	DistrictID        uint           `json:"district_id"`
@@ -91,6 +118,11 @@ type SurveyResponse struct {
 	SubdistrictName   string         `json:"subdistrict_name"`
 	VillageID         uint           `json:"village_id"`
 	VillageName       string         `json:"village_name"`
+
+	// Checklists filled in by the verifiers and their notes of every round, oldest first;
+	// only filled in the survey detail
+	Checklists  []SurveyChecklistResponse `json:"checklists,omitempty"`
+	NoteThreads []SurveyNoteResponse      `json:"verification_notes,omitempty"`
 }
 
 func (s *Survey) Update(newSurvey *Survey) {
@@ -107,7 +139,7 @@ func (s *Survey) Update(newSurvey *Survey) {
 	s.ResourceID = newSurvey.ResourceID
 	s.ProgramID = newSurvey.ProgramID
 	s.Budget = newSurvey.Budget
-	s.Coordinate = newSurvey.Coordinate
+	s.SetCoordinate(newSurvey.Coordinate)
 	s.IsSubmitted = newSurvey.IsSubmitted
 	s.ImagesBefore = newSurvey.ImagesBefore
 	s.ImagesAfter = newSurvey.ImagesAfter
@@ -120,11 +152,11 @@ func (s *Survey) Update(newSurvey *Survey) {
 }
 
 func (s *Survey) UpdateFromInput(input SurveyInput) {
-	var imagesBefore, imagesAfter pq.StringArray
-	if s.StatusRealization == shared.StatusRealProses {
-		imagesBefore = input.Images
-	} else if s.StatusRealization == shared.StatusRealSelesai {
-		imagesAfter = input.Images
+	// Images belong to the realization stage being reported, the other stage keeps its images
+	if input.StatusRealization == shared.RealizationProses {
+		s.ImagesBefore = input.Images
+	} else if input.StatusRealization == shared.RealizationSelesai {
+		s.ImagesAfter = input.Images
 	}
 	s.ID = input.ID
 	s.Name = input.Name
@@ -140,10 +172,7 @@ func (s *Survey) UpdateFromInput(input SurveyInput) {
 	s.ResourceID = input.ResourceID
 	s.ProgramID = input.ProgramID
 	s.Budget = input.Budget
-	s.Coordinate = input.Coordinate
-	s.IsSubmitted = input.IsSubmitted
-	s.ImagesBefore = imagesBefore
-	s.ImagesAfter = imagesAfter
+	s.SetCoordinate(input.Coordinate)
 	s.ProvinceID = input.ProvinceID
 	s.DistrictID = input.DistrictID
 	s.SubdistrictID = input.SubdistrictID
@@ -152,6 +181,16 @@ func (s *Survey) UpdateFromInput(input SurveyInput) {
 	s.UpdatedAt = time.Now()
 }
 
+// SetCoordinate stores the coordinate text and its parsed latitude and longitude.
+// Coordinates that cannot be parsed leave both columns empty.
+func (s *Survey) SetCoordinate(coordinate string) {
+	s.Coordinate = coordinate
+	s.Latitude, s.Longitude = nil, nil
+	if lat, lng, err := shared.ParseCoordinate(coordinate); err == nil {
+		s.Latitude, s.Longitude = &lat, &lng
+	}
+}
+
 func (s *Survey) ToResponse() SurveyResponse {
 	return SurveyResponse{
 		ID:                s.ID,
@@ -178,6 +217,14 @@ func (s *Survey) ToResponse() SurveyResponse {
 		Status:            s.GetStatusSurvey(),
 		StatusBalai:       s.StatusBalai,
 		StatusEselon1:     s.StatusEselon1,
+		RevisionRound:     s.RevisionRound,
+		SubmittedAt:       s.SubmittedAt,
+		StatusChangedAt:   s.StatusChangedAt,
+		ClaimedBy:         s.ClaimedBy,
+		ClaimExpiresAt:    s.ClaimExpiresAt,
+		SLABreachedAt:     s.SLABreachedAt,
+		ReturnedAt:        s.ReturnedAt,
+		ReturnNotes:       s.ReturnNotes,
 		Notes:             s.Notes,
 		ImagesBefore:      s.ImagesBefore,
 		ImagesAfter:       s.ImagesAfter,
@@ -240,29 +287,30 @@ type SurveyInput struct {
 	Budget            uint64         `json:"budget"`
 	Coordinate        string         `json:"coordinate"`   // lat,lng string or GeoJSON
 	IsSubmitted       bool           `json:"is_submitted"` // default false
-	Images            pq.StringArray `json:"images"`
+	Images            pq.StringArray `json:"images"`       // upload IDs from POST /uploads
 	ProvinceID        uint           `json:"province_id" validate:"required"`
 	DistrictID        uint           `json:"district_id" validate:"required"`
 	SubdistrictID     uint           `json:"subdistrict_id" validate:"required"`
 	VillageID         uint           `json:"village_id" validate:"required"`
+	AssignmentID      uint           `json:"assignment_id"`
 	Actor             string         `json:"-"` // CreatedBy, UpdatedBy, DeletedBy
 	Mode              string         `json:"-"` // "create" or "update"
 }
 
-// ToSurvey only used in creating survey
-// if used for updating or viewing, adjust StatusBalai and StatusEselon1
+// ToSurvey only used in creating survey, always as a draft
+// submission is done through the survey workflow
 func (s *SurveyInput) ToSurvey() Survey {
 	var imagesBefore, imagesAfter pq.StringArray
-	if s.StatusRealization == shared.StatusRealProses {
+	if s.StatusRealization == shared.RealizationProses {
 		imagesBefore = s.Images
-	} else if s.StatusRealization == shared.StatusRealSelesai {
+	} else if s.StatusRealization == shared.RealizationSelesai {
 		imagesAfter = s.Images
 	}
 	var id uint
 	if s.Mode == shared.Update {
 		id = s.ID
 	}
-	return Survey{
+	survey := Survey{
 		ID:                id,
 		UserID:            s.UserID,
 		Name:              s.Name,
@@ -279,7 +327,7 @@ func (s *SurveyInput) ToSurvey() Survey {
 		ProgramID:         s.ProgramID,
 		Budget:            s.Budget,
 		Coordinate:        s.Coordinate,
-		IsSubmitted:       s.IsSubmitted,
+		IsSubmitted:       false,
 		StatusBalai:       shared.Pending,
 		StatusEselon1:     shared.Pending,
 		ImagesBefore:      imagesBefore,
@@ -293,6 +341,8 @@ func (s *SurveyInput) ToSurvey() Survey {
 		UpdatedBy:         s.Actor,
 		UpdatedAt:         time.Now(),
 	}
+	survey.SetCoordinate(s.Coordinate)
+	return survey
 }
 
 func (s *SurveyInput) Validate() error {
@@ -317,14 +367,70 @@ func (s *SurveyInput) Validate() error {
 		"SubdistrictID.required":     "Subdistrict is required",
 		"VillageID.required":         "Village is required",
 	}
+	if err := shared.CustomValidate(s, customMessages); err != nil {
+		return err
+	}
+	return s.validateCoordinate()
+}
+
+// validateCoordinate accepts an empty coordinate, otherwise it must parse and lie in Indonesia
+func (s *SurveyInput) validateCoordinate() error {
+	if strings.TrimSpace(s.Coordinate) == "" {
+		return nil
+	}
+	lat, lng, err := shared.ParseCoordinate(s.Coordinate)
+	if err != nil {
+		return errors.New("Coordinate must be 'lat,lng' or a GeoJSON Point")
+	}
+	if !shared.InIndonesia(lat, lng) {
+		return fmt.Errorf("Coordinate %.6f,%.6f is outside Indonesia", lat, lng)
+	}
+	return nil
+}
+
+type SurveyResubmitInput struct {
+	Notes string `json:"notes"` // What was fixed since the last rejection
+	Actor string `json:"-"`
+}
+
+type SurveyReturnInput struct {
+	Notes string `json:"notes" validate:"required"` // What the Balai has to recheck
+	Actor string `json:"-"`
+}
+
+func (s *SurveyReturnInput) Validate() error {
+	customMessages := map[string]string{
+		"Notes.required": "Notes are required when returning a survey to the Balai",
+	}
 	return shared.CustomValidate(s, customMessages)
 }
 
+type SurveyCorrectionInput struct {
+	Reason string      `json:"reason" validate:"required"`
+	Survey SurveyInput `json:"survey" validate:"-"`
+	Actor  string      `json:"-"`
+}
+
+func (s *SurveyCorrectionInput) Validate() error {
+	customMessages := map[string]string{
+		"Reason.required": "Reason is required for a correction request",
+	}
+	if err := shared.CustomValidate(s, customMessages); err != nil {
+		return err
+	}
+	return s.Survey.Validate()
+}
+
 type SurveyActionInput struct {
 	SurveyIDs []string `json:"survey_ids" validate:"required"`
 	Action    string   `json:"action" validate:"required,oneof=Approved Rejected"`
-	Notes     string   `json:"notes" validation:"required_if=Action Rejected"` // Notes for rejection
-	Actor     string   `json:"-"`                                              // Actor who performs the action
+	Notes     string   `json:"notes" validate:"required_if=Action Rejected"` // Notes for rejection
+	Actor     string   `json:"-"`                                            // Actor who performs the action
+	Atomic    bool     `json:"atomic"`                                       // all or nothing, any failure rolls back the batch
+
+	// Answers to the checklist template of each survey's program type and the caller's level,
+	// by survey ID. A survey with a template but no answers has every item unchecked.
+	Checklists map[string][]ChecklistAnswerInput `json:"checklists"`
 }
 
 func (s *SurveyActionInput) Validate() error {
@@ -334,5 +440,35 @@ func (s *SurveyActionInput) Validate() error {
 		"Action.oneof":       "Action must be either 'Approved' or 'Rejected'",
 		"Notes.required_if":  "Notes are required when rejecting a survey",
 	}
-	return shared.CustomValidate(s, customMessages)
+	if err := shared.CustomValidate(s, customMessages); err != nil {
+		return err
+	}
+	for id := range s.Checklists {
+		if !slices.Contains(s.SurveyIDs, id) {
+			return fmt.Errorf("Checklist answers for survey %s, which is not in survey_ids", id)
+		}
+	}
+	return nil
+}
+
+// Reason codes of a survey the bulk action could not be applied to
+const (
+	ActionReasonNotFound        = "NOT_FOUND"        // no such survey
+	ActionReasonNotSubmitted    = "NOT_SUBMITTED"    // still a draft
+	ActionReasonWrongLevel      = "WRONG_LEVEL"      // not waiting on the caller's verification level
+	ActionReasonAlreadyActioned = "ALREADY_ACTIONED" // the caller's level already approved or rejected it
+	ActionReasonOutOfScope      = "OUT_OF_SCOPE"     // outside the caller's Balai
+	ActionReasonClaimedByOther  = "CLAIMED_BY_OTHER" // claimed by another verifier
+	ActionReasonChecklist       = "CHECKLIST"        // checklist answers are invalid or required items are not checked
+	ActionReasonRolledBack      = "ROLLED_BACK"      // could be applied, but another survey of an atomic batch failed
+	ActionReasonError           = "ERROR"            // unexpected failure
+)
+
+// SurveyActionResult is the outcome of a bulk action on one survey
+type SurveyActionResult struct {
+	SurveyID string `json:"survey_id"`
+	Success  bool   `json:"success"`
+	Status   string `json:"status,omitempty"` // status after the action, or the status that refused it
+	Reason   string `json:"reason,omitempty"` // one of the ActionReason codes when not successful
+	Message  string `json:"message,omitempty"`
 }
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+
+	"housing-survey-api/shared"
+)
+
+func TestSurveySetCoordinate(t *testing.T) {
+	var s Survey
+	s.SetCoordinate("-6.2088,106.8456")
+	if s.Latitude == nil || s.Longitude == nil || *s.Latitude != -6.2088 || *s.Longitude != 106.8456 {
+		t.Fatalf("lat,lng not parsed: %v %v", s.Latitude, s.Longitude)
+	}
+
+	// A later unparsable value must not keep the old position
+	s.SetCoordinate("somewhere in Bekasi")
+	if s.Coordinate != "somewhere in Bekasi" || s.Latitude != nil || s.Longitude != nil {
+		t.Errorf("unparsable coordinate: %q %v %v", s.Coordinate, s.Latitude, s.Longitude)
+	}
+}
+
+func TestSurveyInputValidateCoordinate(t *testing.T) {
+	tests := []struct {
+		coordinate string
+		ok         bool
+	}{
+		{"", true},
+		{"-6.2088,106.8456", true},
+		{`{"type":"Point","coordinates":[110.4,-7.0]}`, true},
+		{"106.8456,-6.2088", false}, // lng,lat swapped
+		{"14.5995,120.9842", false}, // Manila
+		{"north of the river", false},
+	}
+	for _, tt := range tests {
+		s := SurveyInput{Coordinate: tt.coordinate}
+		if err := s.validateCoordinate(); (err == nil) != tt.ok {
+			t.Errorf("validateCoordinate(%q) = %v, want ok %v", tt.coordinate, err, tt.ok)
+		}
+	}
+}
+
+func TestSurveyReturnInputValidate(t *testing.T) {
+	if err := (&SurveyReturnInput{}).Validate(); err == nil {
+		t.Error("return without notes accepted")
+	}
+	if err := (&SurveyReturnInput{Notes: "Photo after does not show the roof"}).Validate(); err != nil {
+		t.Errorf("return with notes refused: %v", err)
+	}
+}
+
+func TestSurveyActionInputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   SurveyActionInput
+		wantErr bool
+	}{
+		{name: "approve without notes", input: SurveyActionInput{SurveyIDs: []string{"1"}, Action: shared.Approved}},
+		{name: "reject with notes", input: SurveyActionInput{SurveyIDs: []string{"1"}, Action: shared.Rejected, Notes: "Foto buram"}},
+		{name: "reject without notes", input: SurveyActionInput{SurveyIDs: []string{"1"}, Action: shared.Rejected}, wantErr: true},
+		{name: "unknown action", input: SurveyActionInput{SurveyIDs: []string{"1"}, Action: "Deleted"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.input.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+
+	"housing-survey-api/shared"
+)
+
+// SurveyNote is a note a verifier left when actioning a survey, or a surveyor's reply to
+// one. Every round keeps its notes; Survey.Notes only holds those of the current rejection.
+type SurveyNote struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	SurveyID    uint      `gorm:"index;not null"`
+	ParentID    *uint     `gorm:"index"` // verifier note a reply answers
+	HistoryID   *uint     `gorm:"index"` // transition the note was left with
+	Level       string    `gorm:"type:text;not null"`
+	Action      string    `gorm:"type:text;not null"`
+	Round       uint      `gorm:"default:0"` // Survey.RevisionRound at the time of the note
+	AuthorID    uint      `gorm:"index"`
+	AuthorEmail string    `gorm:"type:text"`
+	AuthorRole  string    `gorm:"type:text"`
+	Body        string    `gorm:"type:text;not null"`
+	CreatedAt   time.Time `gorm:"index"`
+}
+
+type SurveyNoteResponse struct {
+	ID          uint                 `json:"id"`
+	SurveyID    uint                 `json:"survey_id"`
+	ParentID    *uint                `json:"parent_id"`
+	Level       string               `json:"level"`
+	Action      string               `json:"action"`
+	Round       uint                 `json:"round"`
+	AuthorID    uint                 `json:"author_id"`
+	AuthorEmail string               `json:"author_email"`
+	AuthorRole  string               `json:"author_role"`
+	Body        string               `json:"body"`
+	CreatedAt   time.Time            `json:"created_at"`
+	Replies     []SurveyNoteResponse `json:"replies,omitempty"`
+}
+
+func (n *SurveyNote) ToResponse() SurveyNoteResponse {
+	return SurveyNoteResponse{
+		ID:          n.ID,
+		SurveyID:    n.SurveyID,
+		ParentID:    n.ParentID,
+		Level:       n.Level,
+		Action:      n.Action,
+		Round:       n.Round,
+		AuthorID:    n.AuthorID,
+		AuthorEmail: n.AuthorEmail,
+		AuthorRole:  n.AuthorRole,
+		Body:        n.Body,
+		CreatedAt:   n.CreatedAt,
+	}
+}
+
+// ToSurveyNoteThreads nests the replies under the notes they answer. Notes are expected
+// oldest first and keep that order.
+func ToSurveyNoteThreads(notes []SurveyNote) []SurveyNoteResponse {
+	threads := []SurveyNoteResponse{}
+	index := make(map[uint]int)
+	for _, n := range notes {
+		if n.ParentID == nil {
+			index[n.ID] = len(threads)
+			threads = append(threads, n.ToResponse())
+		}
+	}
+	for _, n := range notes {
+		if n.ParentID == nil {
+			continue
+		}
+		if i, ok := index[*n.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, n.ToResponse())
+		}
+	}
+	return threads
+}
+
+// IsVerifierNote reports whether the note was left by a verifier, the notes surveyors reply to
+func (n *SurveyNote) IsVerifierNote() bool {
+	return n.ParentID == nil && n.Level != shared.LevelSurveyor
+}
+
+type SurveyNoteReplyInput struct {
+	Body  string `json:"body" validate:"required"`
+	Actor string `json:"-"`
+}
+
+func (i *SurveyNoteReplyInput) Validate() error {
+	return shared.CustomValidate(i, map[string]string{
+		"Body.required": "Reply is required",
+	})
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+
+	"housing-survey-api/shared"
+)
+
+func TestToSurveyNoteThreads(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	notes := []SurveyNote{
+		{ID: 1, Level: shared.LevelBalai, Action: shared.Rejected, Body: "Foto buram"},
+		{ID: 2, ParentID: id(1), Level: shared.LevelSurveyor, Body: "Sudah difoto ulang"},
+		{ID: 3, Level: shared.LevelEselon1, Action: shared.Rejected, Round: 1, Body: "Anggaran tidak sesuai"},
+		{ID: 4, ParentID: id(1), Level: shared.LevelSurveyor, Body: "Lihat lampiran"},
+		{ID: 5, ParentID: id(42), Level: shared.LevelSurveyor, Body: "reply to a note of another survey"},
+	}
+
+	threads := ToSurveyNoteThreads(notes)
+	if len(threads) != 2 || threads[0].ID != 1 || threads[1].ID != 3 {
+		t.Fatalf("threads = %+v, want notes 1 and 3", threads)
+	}
+	if r := threads[0].Replies; len(r) != 2 || r[0].ID != 2 || r[1].ID != 4 {
+		t.Errorf("replies of note 1 = %+v, want 2 then 4", r)
+	}
+	if r := threads[1].Replies; len(r) != 0 {
+		t.Errorf("note 3 has replies %+v", r)
+	}
+
+	if empty := ToSurveyNoteThreads(nil); empty == nil || len(empty) != 0 {
+		t.Errorf("no notes gives %v, want an empty list", empty)
+	}
+}
+
+func TestSurveyNoteIsVerifierNote(t *testing.T) {
+	parent := uint(1)
+	notes := map[string]struct {
+		note SurveyNote
+		want bool
+	}{
+		"balai rejection":   {SurveyNote{Level: shared.LevelBalai}, true},
+		"eselon 1 return":   {SurveyNote{Level: shared.LevelEselon1}, true},
+		"surveyor reply":    {SurveyNote{Level: shared.LevelSurveyor, ParentID: &parent}, false},
+		"surveyor top note": {SurveyNote{Level: shared.LevelSurveyor}, false},
+	}
+	for name, c := range notes {
+		if got := c.note.IsVerifierNote(); got != c.want {
+			t.Errorf("%s: IsVerifierNote() = %v", name, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// SurveyRow is a survey joined with the names shown in exports, scanned row by row
+// so national exports never hold every survey in memory.
+type SurveyRow struct {
+	ID                uint
+	UserEmail         string
+	Name              string
+	Address           string
+	Type              string
+	MbrStatus         string
+	Year              uint
+	UnitTarget        uint
+	StatusRealization string
+	YearRealization   uint
+	MonthRealization  uint
+	Budget            uint64
+	IsSubmitted       bool
+	StatusBalai       string
+	StatusEselon1     string
+	Coordinate        string
+	Latitude          *float64
+	Longitude         *float64
+	ProgramTypeName   string
+	ResourceName      string
+	ProgramName       string
+	ProvinceName      string
+	DistrictName      string
+	SubdistrictName   string
+	VillageName       string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// SurveyRowColumns selects a SurveyRow, used with SurveyRowJoins
+const SurveyRowColumns = "surveys.id, users.email AS user_email, surveys.name, surveys.address, surveys.type, " +
+	"surveys.mbr_status, surveys.year, surveys.unit_target, surveys.status_realization, " +
+	"surveys.year_realization, surveys.month_realization, surveys.budget, surveys.is_submitted, " +
+	"surveys.status_balai, surveys.status_eselon1, surveys.coordinate, surveys.latitude, surveys.longitude, " +
+	"program_types.name AS program_type_name, resources.name AS resource_name, programs.name AS program_name, " +
+	"provinces.name AS province_name, districts.name AS district_name, " +
+	"subdistricts.name AS subdistrict_name, villages.name AS village_name, " +
+	"surveys.created_at, surveys.updated_at"
+
+const SurveyRowJoins = "LEFT JOIN users ON users.id = surveys.user_id " +
+	"LEFT JOIN program_types ON program_types.id = surveys.program_type_id " +
+	"LEFT JOIN resources ON resources.id = surveys.resource_id " +
+	"LEFT JOIN programs ON programs.id = surveys.program_id " +
+	"LEFT JOIN provinces ON provinces.id = surveys.province_id " +
+	"LEFT JOIN districts ON districts.id = surveys.district_id " +
+	"LEFT JOIN subdistricts ON subdistricts.id = surveys.subdistrict_id " +
+	"LEFT JOIN villages ON villages.id = surveys.village_id"
+
+func (r *SurveyRow) Status() string {
+	survey := Survey{IsSubmitted: r.IsSubmitted, StatusBalai: r.StatusBalai, StatusEselon1: r.StatusEselon1}
+	return survey.GetStatusSurvey()
+}
+
+// SurveyExportHeaders are the column headers of survey spreadsheet exports, matching SurveyRow.ExportValues
+var SurveyExportHeaders = []string{
+	"ID", "Survey Name", "Address", "Type", "MBR Status", "Year", "Unit Target",
+	"Realization Status", "Realization Year", "Realization Month",
+	"Program Type", "Resource", "Program", "Budget",
+	"Status", "Balai Status", "Eselon 1 Status",
+	"Province", "District", "Subdistrict", "Village",
+	"Latitude", "Longitude", "Coordinate", "Surveyor Email", "Created At", "Updated At",
+}
+
+func (r *SurveyRow) ExportValues() []interface{} {
+	var lat, lng interface{}
+	if r.Latitude != nil && r.Longitude != nil {
+		lat, lng = *r.Latitude, *r.Longitude
+	}
+	return []interface{}{
+		r.ID, r.Name, r.Address, r.Type, r.MbrStatus, r.Year, r.UnitTarget,
+		r.StatusRealization, r.YearRealization, r.MonthRealization,
+		r.ProgramTypeName, r.ResourceName, r.ProgramName, r.Budget,
+		r.Status(), r.StatusBalai, r.StatusEselon1,
+		r.ProvinceName, r.DistrictName, r.SubdistrictName, r.VillageName,
+		lat, lng, r.Coordinate, r.UserEmail, r.CreatedAt, r.UpdatedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// SurveyStatistic is the count, units and budget of the surveys of one surveyor sharing
+// the same region, program, year and derived status. Dashboards and reports read these
+// instead of scanning surveys; the rows of a surveyor are rebuilt whenever one of their
+// surveys changes, see services.RefreshSurveyStatistics.
+//
+// The columns are named after their survey columns so survey filters and role scopes
+// apply unchanged when the table is queried as "survey_statistics AS surveys".
+type SurveyStatistic struct {
+	ID            uint   `gorm:"primaryKey;autoIncrement"`
+	UserID        uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	ProvinceID    uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	ProgramTypeID uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	ResourceID    uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	ProgramID     uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	Year          uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	Status        string `gorm:"type:text;not null;uniqueIndex:idx_survey_statistics_group"` // see SurveyStatusSQL
+	// Realization year and month of surveys realized ("Selesai"), 0 otherwise
+	RealizedYear  uint `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	RealizedMonth uint `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	// Year and month of first submission, 0 for drafts
+	SubmittedYear  uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	SubmittedMonth uint   `gorm:"not null;uniqueIndex:idx_survey_statistics_group"`
+	SurveyCount    int64  `gorm:"not null"`
+	UnitTarget     uint64 `gorm:"not null"` // sum of the surveys' unit targets
+	Budget         uint64 `gorm:"not null"` // sum of the surveys' budgets
+}
+
+// SurveyStatisticState is the single row recording how far the statistics are refreshed
+type SurveyStatisticState struct {
+	ID uint `gorm:"primaryKey"`
+	// Surveys changed since Watermark (less a safety overlap) are picked up by the next refresh
+	Watermark   time.Time
+	RefreshedAt time.Time
+}
+
+// SurveyStatisticStateID is the primary key of the only SurveyStatisticState row
+const SurveyStatisticStateID = 1
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// SurveyStatusHistory records every workflow transition of a survey.
+type SurveyStatusHistory struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	SurveyID   uint      `gorm:"index;not null"`
+	FromStatus string    `gorm:"type:text"`
+	ToStatus   string    `gorm:"type:text;not null"`
+	Action     string    `gorm:"type:text;not null"`
+	ActorID    uint      `gorm:"index"`
+	ActorEmail string    `gorm:"type:text"`
+	ActorRole  string    `gorm:"type:text"`
+	Notes      string    `gorm:"type:text"`
+	Round      uint      `gorm:"default:0"` // Survey.RevisionRound at the time of the transition
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (SurveyStatusHistory) TableName() string {
+	return "survey_status_history"
+}
+
+type SurveyStatusHistoryResponse struct {
+	ID         uint      `json:"id"`
+	SurveyID   uint      `json:"survey_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Action     string    `json:"action"`
+	ActorID    uint      `json:"actor_id"`
+	ActorEmail string    `json:"actor_email"`
+	ActorRole  string    `json:"actor_role"`
+	Notes      string    `json:"notes"`
+	Round      uint      `json:"round"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (h *SurveyStatusHistory) ToResponse() SurveyStatusHistoryResponse {
+	return SurveyStatusHistoryResponse{
+		ID:         h.ID,
+		SurveyID:   h.SurveyID,
+		FromStatus: h.FromStatus,
+		ToStatus:   h.ToStatus,
+		Action:     h.Action,
+		ActorID:    h.ActorID,
+		ActorEmail: h.ActorEmail,
+		ActorRole:  h.ActorRole,
+		Notes:      h.Notes,
+		Round:      h.Round,
+		CreatedAt:  h.CreatedAt,
+	}
+}
+
+func ToSurveyStatusHistoryResponses(histories []SurveyStatusHistory) []SurveyStatusHistoryResponse {
+	res := make([]SurveyStatusHistoryResponse, len(histories))
+	for i, h := range histories {
+		res[i] = h.ToResponse()
+	}
+	return res
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// SurveyVersion is a full snapshot of a survey's data after a create or update.
+type SurveyVersion struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	SurveyID   uint      `gorm:"uniqueIndex:idx_survey_versions_survey_version;not null"`
+	Version    uint      `gorm:"uniqueIndex:idx_survey_versions_survey_version;not null"`
+	Action     string    `gorm:"type:text;not null"` // Create, Update, Correction or Baseline
+	Snapshot   []byte    `gorm:"type:jsonb;not null"`
+	ActorID    uint      `gorm:"index"`
+	ActorEmail string    `gorm:"type:text"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// SurveySnapshot holds the survey fields a surveyor can change.
+type SurveySnapshot struct {
+	Name              string         `json:"name"`
+	Address           string         `json:"address"`
+	Type              string         `json:"type"`
+	MbrStatus         string         `json:"mbr_status"`
+	Year              uint           `json:"year"`
+	UnitTarget        uint           `json:"unit_target"`
+	StatusRealization string         `json:"status_realization"`
+	YearRealization   uint           `json:"year_realization"`
+	MonthRealization  uint           `json:"month_realization"`
+	ProgramTypeID     uint           `json:"program_type_id"`
+	ResourceID        uint           `json:"resource_id"`
+	ProgramID         uint           `json:"program_id"`
+	Budget            uint64         `json:"budget"`
+	Coordinate        string         `json:"coordinate"`
+	ImagesBefore      pq.StringArray `json:"images_before"`
+	ImagesAfter       pq.StringArray `json:"images_after"`
+	ProvinceID        uint           `json:"province_id"`
+	DistrictID        uint           `json:"district_id"`
+	SubdistrictID     uint           `json:"subdistrict_id"`
+	VillageID         uint           `json:"village_id"`
+}
+
+func (s *Survey) Snapshot() SurveySnapshot {
+	return SurveySnapshot{
+		Name:              s.Name,
+		Address:           s.Address,
+		Type:              s.Type,
+		MbrStatus:         s.MbrStatus,
+		Year:              s.Year,
+		UnitTarget:        s.UnitTarget,
+		StatusRealization: s.StatusRealization,
+		YearRealization:   s.YearRealization,
+		MonthRealization:  s.MonthRealization,
+		ProgramTypeID:     s.ProgramTypeID,
+		ResourceID:        s.ResourceID,
+		ProgramID:         s.ProgramID,
+		Budget:            s.Budget,
+		Coordinate:        s.Coordinate,
+		ImagesBefore:      s.ImagesBefore,
+		ImagesAfter:       s.ImagesAfter,
+		ProvinceID:        s.ProvinceID,
+		DistrictID:        s.DistrictID,
+		SubdistrictID:     s.SubdistrictID,
+		VillageID:         s.VillageID,
+	}
+}
+
+// SurveyFieldDiff is a single field that changed between two snapshots
+type SurveyFieldDiff struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// DiffSurveySnapshots lists the fields that differ from a to b, named by their JSON keys.
+func DiffSurveySnapshots(a, b SurveySnapshot) []SurveyFieldDiff {
+	diffs := []SurveyFieldDiff{}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+	for i := 0; i < t.NumField(); i++ {
+		from, to := va.Field(i).Interface(), vb.Field(i).Interface()
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		field := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		diffs = append(diffs, SurveyFieldDiff{Field: field, From: from, To: to})
+	}
+	return diffs
+}
+
+func (v *SurveyVersion) GetSnapshot() (SurveySnapshot, error) {
+	var snapshot SurveySnapshot
+	err := json.Unmarshal(v.Snapshot, &snapshot)
+	return snapshot, err
+}
+
+type SurveyVersionResponse struct {
+	ID         uint           `json:"id"`
+	SurveyID   uint           `json:"survey_id"`
+	Version    uint           `json:"version"`
+	Action     string         `json:"action"`
+	ActorID    uint           `json:"actor_id"`
+	ActorEmail string         `json:"actor_email"`
+	Snapshot   SurveySnapshot `json:"snapshot"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func (v *SurveyVersion) ToResponse() SurveyVersionResponse {
+	snapshot, _ := v.GetSnapshot()
+	return SurveyVersionResponse{
+		ID:         v.ID,
+		SurveyID:   v.SurveyID,
+		Version:    v.Version,
+		Action:     v.Action,
+		ActorID:    v.ActorID,
+		ActorEmail: v.ActorEmail,
+		Snapshot:   snapshot,
+		CreatedAt:  v.CreatedAt,
+	}
+}
+
+func ToSurveyVersionResponses(versions []SurveyVersion) []SurveyVersionResponse {
+	res := make([]SurveyVersionResponse, len(versions))
+	for i, v := range versions {
+		res[i] = v.ToResponse()
+	}
+	return res
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestDiffSurveySnapshots(t *testing.T) {
+	base := SurveySnapshot{
+		Name:         "Rumah A",
+		Year:         2024,
+		Budget:       1000,
+		ImagesBefore: pq.StringArray{"a", "b"},
+	}
+
+	tests := []struct {
+		name   string
+		change func(s *SurveySnapshot)
+		want   []SurveyFieldDiff
+	}{
+		{
+			name:   "no change",
+			change: func(s *SurveySnapshot) {},
+			want:   []SurveyFieldDiff{},
+		},
+		{
+			name:   "one field",
+			change: func(s *SurveySnapshot) { s.Name = "Rumah B" },
+			want:   []SurveyFieldDiff{{Field: "name", From: "Rumah A", To: "Rumah B"}},
+		},
+		{
+			name: "fields in struct order",
+			change: func(s *SurveySnapshot) {
+				s.Budget = 2000
+				s.Year = 2025
+			},
+			want: []SurveyFieldDiff{
+				{Field: "year", From: uint(2024), To: uint(2025)},
+				{Field: "budget", From: uint64(1000), To: uint64(2000)},
+			},
+		},
+		{
+			name:   "slice contents",
+			change: func(s *SurveySnapshot) { s.ImagesBefore = pq.StringArray{"a", "c"} },
+			want: []SurveyFieldDiff{
+				{Field: "images_before", From: pq.StringArray{"a", "b"}, To: pq.StringArray{"a", "c"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			changed.ImagesBefore = append(pq.StringArray{}, base.ImagesBefore...)
+			tt.change(&changed)
+			if got := DiffSurveySnapshots(base, changed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSurveySnapshots() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
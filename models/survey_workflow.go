@@ -0,0 +1,144 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"housing-survey-api/shared"
+)
+
+// SurveyTransition is a single allowed move in the survey verification workflow.
+type SurveyTransition struct {
+	From   string // derived status before the action, see Survey.GetStatusSurvey
+	Action string
+	To     string // derived status after the action
+	Level  string // workflow level allowed to perform the action
+}
+
+// SurveyTransitions is the whole verification workflow:
+// draft → waiting Balai → waiting Eselon 1 → verified/rejected.
+// Any move not listed here is refused.
+var SurveyTransitions = []SurveyTransition{
+	{From: "", Action: shared.ActionCreate, To: shared.StatusDraft, Level: shared.LevelSurveyor},
+	{From: shared.StatusDraft, Action: shared.ActionSubmit, To: shared.StatusWaitingBalai, Level: shared.LevelSurveyor},
+	{From: shared.StatusWaitingBalai, Action: shared.Approved, To: shared.StatusWaitingEselon1, Level: shared.LevelBalai},
+	{From: shared.StatusWaitingBalai, Action: shared.Rejected, To: shared.StatusRejectedBalai, Level: shared.LevelBalai},
+	{From: shared.StatusWaitingEselon1, Action: shared.Approved, To: shared.StatusVerified, Level: shared.LevelEselon1},
+	{From: shared.StatusWaitingEselon1, Action: shared.Rejected, To: shared.StatusRejectedEselon1, Level: shared.LevelEselon1},
+	// Eselon 1 may ask the Balai to recheck a survey without rejecting it to the surveyor
+	{From: shared.StatusWaitingEselon1, Action: shared.ActionReturn, To: shared.StatusWaitingBalai, Level: shared.LevelEselon1},
+	// Resubmission only resets the level that rejected the survey
+	{From: shared.StatusRejectedBalai, Action: shared.ActionResubmit, To: shared.StatusWaitingBalai, Level: shared.LevelSurveyor},
+	{From: shared.StatusRejectedEselon1, Action: shared.ActionResubmit, To: shared.StatusWaitingEselon1, Level: shared.LevelSurveyor},
+	// A correction on a verified survey goes through the whole verification again
+	{From: shared.StatusVerified, Action: shared.ActionCorrect, To: shared.StatusWaitingBalai, Level: shared.LevelSurveyor},
+}
+
+// SurveyStatusSQL is Survey.GetStatusSurvey as an SQL expression over the surveys table,
+// for grouping and filtering surveys by their derived status in the database.
+var SurveyStatusSQL = fmt.Sprintf("CASE"+
+	" WHEN NOT surveys.is_submitted THEN '%s'"+
+	" WHEN surveys.status_balai = '%s' AND surveys.status_eselon1 = '%s' THEN '%s'"+
+	" WHEN surveys.status_balai = '%s' AND surveys.status_eselon1 = '%s' THEN '%s'"+
+	" WHEN surveys.status_balai = '%s' AND surveys.status_eselon1 = '%s' THEN '%s'"+
+	" WHEN surveys.status_balai = '%s' THEN '%s'"+
+	" WHEN surveys.status_eselon1 = '%s' THEN '%s'"+
+	" ELSE 'unknown' END",
+	shared.StatusDraft,
+	shared.Pending, shared.Pending, shared.StatusWaitingBalai,
+	shared.Approved, shared.Pending, shared.StatusWaitingEselon1,
+	shared.Approved, shared.Approved, shared.StatusVerified,
+	shared.Rejected, shared.StatusRejectedBalai,
+	shared.Rejected, shared.StatusRejectedEselon1,
+)
+
+// FindSurveyTransition looks up the transition for an action taken from the given status.
+func FindSurveyTransition(from, action string) (SurveyTransition, bool) {
+	for _, t := range SurveyTransitions {
+		if t.From == from && t.Action == action {
+			return t, true
+		}
+	}
+	return SurveyTransition{}, false
+}
+
+// SurveyStatuses lists the derived statuses in the order a survey moves through verification.
+var SurveyStatuses = []string{
+	shared.StatusDraft,
+	shared.StatusWaitingBalai,
+	shared.StatusRejectedBalai,
+	shared.StatusWaitingEselon1,
+	shared.StatusRejectedEselon1,
+	shared.StatusVerified,
+}
+
+// IsSurveyStatus reports whether status is one of the derived survey statuses.
+func IsSurveyStatus(status string) bool {
+	for _, s := range SurveyStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSurveyPending reports whether the status is waiting on a verifier.
+func IsSurveyPending(status string) bool {
+	return status == shared.StatusWaitingBalai || status == shared.StatusWaitingEselon1
+}
+
+// IsSurveyRejected reports whether the status is a rejection at any level.
+func IsSurveyRejected(status string) bool {
+	return status == shared.StatusRejectedBalai || status == shared.StatusRejectedEselon1
+}
+
+// EditLockReason explains why the survey owner cannot edit the survey directly,
+// or returns an empty string when the survey is editable (draft or rejected).
+func (s *Survey) EditLockReason() string {
+	status := s.GetStatusSurvey()
+	switch {
+	case IsSurveyPending(status):
+		return "Survey is waiting for verification and cannot be changed until it is rejected"
+	case status == shared.StatusVerified:
+		return "Verified survey can only be changed through a correction request"
+	}
+	return ""
+}
+
+// ApplyStatus sets the stored status columns so that GetStatusSurvey returns the given status.
+func (s *Survey) ApplyStatus(status string) {
+	switch status {
+	case shared.StatusDraft:
+		s.IsSubmitted, s.StatusBalai, s.StatusEselon1 = false, shared.Pending, shared.Pending
+	case shared.StatusWaitingBalai:
+		s.IsSubmitted, s.StatusBalai, s.StatusEselon1 = true, shared.Pending, shared.Pending
+	case shared.StatusWaitingEselon1:
+		s.IsSubmitted, s.StatusBalai, s.StatusEselon1 = true, shared.Approved, shared.Pending
+	case shared.StatusVerified:
+		s.IsSubmitted, s.StatusBalai, s.StatusEselon1 = true, shared.Approved, shared.Approved
+	case shared.StatusRejectedBalai:
+		s.IsSubmitted, s.StatusBalai, s.StatusEselon1 = true, shared.Rejected, shared.Pending
+	case shared.StatusRejectedEselon1:
+		s.IsSubmitted, s.StatusBalai, s.StatusEselon1 = true, shared.Approved, shared.Rejected
+	}
+}
+
+// IsClaimedByOther reports whether a verifier other than userID holds an unexpired claim
+func (s *Survey) IsClaimedByOther(userID uint, now time.Time) bool {
+	return s.ClaimedBy != nil && *s.ClaimedBy != userID &&
+		s.ClaimExpiresAt != nil && s.ClaimExpiresAt.After(now)
+}
+
+// Claim gives userID the survey until now+ttl, claiming it again extends the claim
+func (s *Survey) Claim(userID uint, now time.Time, ttl time.Duration) {
+	expires := now.Add(ttl)
+	if s.ClaimedBy == nil || *s.ClaimedBy != userID {
+		s.ClaimedAt = &now
+	}
+	s.ClaimedBy, s.ClaimExpiresAt = &userID, &expires
+}
+
+// ReleaseClaim puts the survey back in the queue
+func (s *Survey) ReleaseClaim() {
+	s.ClaimedBy, s.ClaimedAt, s.ClaimExpiresAt = nil, nil, nil
+}
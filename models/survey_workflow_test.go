@@ -0,0 +1,251 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"housing-survey-api/shared"
+)
+
+// walk applies the actions to a new survey and returns the status after each one,
+// stopping at the first action the workflow refuses
+func walk(t *testing.T, actions ...string) []string {
+	t.Helper()
+	var survey Survey
+	from := ""
+	var statuses []string
+	for _, action := range actions {
+		tr, ok := FindSurveyTransition(from, action)
+		if !ok {
+			t.Fatalf("%s from %q refused, statuses so far %v", action, from, statuses)
+		}
+		survey.ApplyStatus(tr.To)
+		from = survey.GetStatusSurvey()
+		if from != tr.To {
+			t.Fatalf("%s stored %q but reads back as %q", action, tr.To, from)
+		}
+		statuses = append(statuses, from)
+	}
+	return statuses
+}
+
+func TestSurveyWorkflowPaths(t *testing.T) {
+	paths := map[string]struct {
+		actions []string
+		want    []string
+	}{
+		"verified": {
+			actions: []string{shared.ActionCreate, shared.ActionSubmit, shared.Approved, shared.Approved},
+			want:    []string{shared.StatusDraft, shared.StatusWaitingBalai, shared.StatusWaitingEselon1, shared.StatusVerified},
+		},
+		"rejected by Balai": {
+			actions: []string{shared.ActionCreate, shared.ActionSubmit, shared.Rejected},
+			want:    []string{shared.StatusDraft, shared.StatusWaitingBalai, shared.StatusRejectedBalai},
+		},
+		"rejected by Eselon 1": {
+			actions: []string{shared.ActionCreate, shared.ActionSubmit, shared.Approved, shared.Rejected},
+			want:    []string{shared.StatusDraft, shared.StatusWaitingBalai, shared.StatusWaitingEselon1, shared.StatusRejectedEselon1},
+		},
+		"returned to Balai by Eselon 1": {
+			actions: []string{shared.ActionCreate, shared.ActionSubmit, shared.Approved, shared.ActionReturn, shared.Approved, shared.Approved},
+			want:    []string{shared.StatusDraft, shared.StatusWaitingBalai, shared.StatusWaitingEselon1, shared.StatusWaitingBalai, shared.StatusWaitingEselon1, shared.StatusVerified},
+		},
+		"corrected after verification": {
+			actions: []string{shared.ActionCreate, shared.ActionSubmit, shared.Approved, shared.Approved, shared.ActionCorrect, shared.Approved},
+			want:    []string{shared.StatusDraft, shared.StatusWaitingBalai, shared.StatusWaitingEselon1, shared.StatusVerified, shared.StatusWaitingBalai, shared.StatusWaitingEselon1},
+		},
+	}
+	for name, p := range paths {
+		t.Run(name, func(t *testing.T) {
+			got := walk(t, p.actions...)
+			if len(got) != len(p.want) {
+				t.Fatalf("statuses = %v, want %v", got, p.want)
+			}
+			for i := range got {
+				if got[i] != p.want[i] {
+					t.Errorf("after %s: %q, want %q", p.actions[i], got[i], p.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSurveyTransitionLevels(t *testing.T) {
+	levels := map[[2]string]string{
+		{"", shared.ActionCreate}:                          shared.LevelSurveyor,
+		{shared.StatusDraft, shared.ActionSubmit}:          shared.LevelSurveyor,
+		{shared.StatusWaitingBalai, shared.Approved}:       shared.LevelBalai,
+		{shared.StatusWaitingBalai, shared.Rejected}:       shared.LevelBalai,
+		{shared.StatusWaitingEselon1, shared.Approved}:     shared.LevelEselon1,
+		{shared.StatusWaitingEselon1, shared.Rejected}:     shared.LevelEselon1,
+		{shared.StatusVerified, shared.ActionCorrect}:      shared.LevelSurveyor,
+		{shared.StatusWaitingEselon1, shared.ActionReturn}: shared.LevelEselon1,
+	}
+	for move, level := range levels {
+		tr, ok := FindSurveyTransition(move[0], move[1])
+		if !ok || tr.Level != level {
+			t.Errorf("%s from %q: level %q (found %v), want %q", move[1], move[0], tr.Level, ok, level)
+		}
+	}
+}
+
+func TestFindSurveyTransitionRefuses(t *testing.T) {
+	refused := [][2]string{
+		{shared.StatusDraft, shared.Approved},
+		{shared.StatusDraft, shared.Rejected},
+		{shared.StatusWaitingBalai, shared.ActionSubmit},
+		{shared.StatusVerified, shared.Approved},
+		{shared.StatusVerified, shared.Rejected},
+		{shared.StatusRejectedBalai, shared.Approved},
+		{shared.StatusRejectedEselon1, shared.Approved},
+		{shared.StatusWaitingBalai, shared.ActionCreate},
+		{shared.StatusDraft, shared.ActionCorrect},
+		{shared.StatusWaitingBalai, shared.ActionCorrect},
+		{shared.StatusWaitingEselon1, shared.ActionCorrect},
+		{shared.StatusRejectedBalai, shared.ActionCorrect},
+		{shared.StatusRejectedEselon1, shared.ActionCorrect},
+		{shared.StatusDraft, shared.ActionReturn},
+		{shared.StatusWaitingBalai, shared.ActionReturn},
+		{shared.StatusVerified, shared.ActionReturn},
+		{shared.StatusRejectedEselon1, shared.ActionReturn},
+	}
+	for _, move := range refused {
+		if tr, ok := FindSurveyTransition(move[0], move[1]); ok {
+			t.Errorf("%s from %q allowed, leads to %q", move[1], move[0], tr.To)
+		}
+	}
+}
+
+func TestSurveyResubmit(t *testing.T) {
+	cases := []struct {
+		from           string
+		to             string
+		balai, eselon1 string
+	}{
+		// Balai reviews again from scratch, Eselon 1 had not reviewed yet
+		{shared.StatusRejectedBalai, shared.StatusWaitingBalai, shared.Pending, shared.Pending},
+		// Balai's approval stands, only Eselon 1 reviews again
+		{shared.StatusRejectedEselon1, shared.StatusWaitingEselon1, shared.Approved, shared.Pending},
+	}
+	for _, c := range cases {
+		tr, ok := FindSurveyTransition(c.from, shared.ActionResubmit)
+		if !ok {
+			t.Errorf("resubmit from %q refused", c.from)
+			continue
+		}
+		if tr.To != c.to || tr.Level != shared.LevelSurveyor {
+			t.Errorf("resubmit from %q: to %q at level %q, want %q at %q", c.from, tr.To, tr.Level, c.to, shared.LevelSurveyor)
+		}
+		var survey Survey
+		survey.ApplyStatus(c.from)
+		survey.ApplyStatus(tr.To)
+		if survey.StatusBalai != c.balai || survey.StatusEselon1 != c.eselon1 {
+			t.Errorf("resubmit from %q: balai=%q eselon1=%q, want %q/%q",
+				c.from, survey.StatusBalai, survey.StatusEselon1, c.balai, c.eselon1)
+		}
+	}
+
+	for _, from := range []string{shared.StatusDraft, shared.StatusWaitingBalai, shared.StatusWaitingEselon1, shared.StatusVerified} {
+		if _, ok := FindSurveyTransition(from, shared.ActionResubmit); ok {
+			t.Errorf("resubmit from %q allowed", from)
+		}
+	}
+}
+
+func TestSurveyStatusPredicates(t *testing.T) {
+	statuses := []struct {
+		status            string
+		pending, rejected bool
+	}{
+		{shared.StatusDraft, false, false},
+		{shared.StatusWaitingBalai, true, false},
+		{shared.StatusWaitingEselon1, true, false},
+		{shared.StatusVerified, false, false},
+		{shared.StatusRejectedBalai, false, true},
+		{shared.StatusRejectedEselon1, false, true},
+	}
+	for _, s := range statuses {
+		if got := IsSurveyPending(s.status); got != s.pending {
+			t.Errorf("IsSurveyPending(%q) = %v", s.status, got)
+		}
+		if got := IsSurveyRejected(s.status); got != s.rejected {
+			t.Errorf("IsSurveyRejected(%q) = %v", s.status, got)
+		}
+	}
+}
+
+func TestSurveyEditLockReason(t *testing.T) {
+	editable := map[string]bool{
+		shared.StatusDraft:           true,
+		shared.StatusWaitingBalai:    false,
+		shared.StatusWaitingEselon1:  false,
+		shared.StatusVerified:        false,
+		shared.StatusRejectedBalai:   true,
+		shared.StatusRejectedEselon1: true,
+	}
+	for status, want := range editable {
+		var survey Survey
+		survey.ApplyStatus(status)
+		reason := survey.EditLockReason()
+		if (reason == "") != want {
+			t.Errorf("%s: lock reason %q, editable want %v", status, reason, want)
+		}
+	}
+
+	var verified Survey
+	verified.ApplyStatus(shared.StatusVerified)
+	if reason := verified.EditLockReason(); !strings.Contains(reason, "correction request") {
+		t.Errorf("verified lock reason %q does not point to correction requests", reason)
+	}
+}
+
+func TestIsSurveyStatus(t *testing.T) {
+	for _, tr := range SurveyTransitions {
+		if !IsSurveyStatus(tr.To) {
+			t.Errorf("transition %s leads to %q, not a survey status", tr.Action, tr.To)
+		}
+	}
+	for _, status := range []string{"", shared.Pending, shared.Approved, "unknown"} {
+		if IsSurveyStatus(status) {
+			t.Errorf("IsSurveyStatus(%q) = true", status)
+		}
+	}
+}
+
+func TestSurveyClaim(t *testing.T) {
+	now := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	var s Survey
+	if s.IsClaimedByOther(1, now) {
+		t.Fatal("unclaimed survey reported as claimed")
+	}
+
+	s.Claim(1, now, 30*time.Minute)
+	if !s.IsClaimedByOther(2, now.Add(29*time.Minute)) {
+		t.Error("claim does not keep other verifiers off")
+	}
+	if s.IsClaimedByOther(1, now.Add(29*time.Minute)) {
+		t.Error("claim blocks its own verifier")
+	}
+	if s.IsClaimedByOther(2, now.Add(30*time.Minute)) {
+		t.Error("expired claim still blocks")
+	}
+
+	// Claiming again extends the claim but keeps when it started
+	s.Claim(1, now.Add(20*time.Minute), 30*time.Minute)
+	if !s.ClaimedAt.Equal(now) || !s.ClaimExpiresAt.Equal(now.Add(50*time.Minute)) {
+		t.Errorf("extended claim: from %v until %v", s.ClaimedAt, s.ClaimExpiresAt)
+	}
+
+	// Taking over an expired claim starts a new one
+	later := now.Add(time.Hour)
+	s.Claim(2, later, 30*time.Minute)
+	if *s.ClaimedBy != 2 || !s.ClaimedAt.Equal(later) {
+		t.Errorf("taken over claim: by %d from %v", *s.ClaimedBy, s.ClaimedAt)
+	}
+
+	s.ReleaseClaim()
+	if s.ClaimedBy != nil || s.ClaimedAt != nil || s.ClaimExpiresAt != nil {
+		t.Error("ReleaseClaim left claim columns set")
+	}
+}
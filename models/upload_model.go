@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Upload is a file kept in storage.Storage, referenced by ID from surveys and comments.
+type Upload struct {
+	ID       string `gorm:"type:uuid;primaryKey"`
+	UserID   *uint  `gorm:"index"`              // uploader, only they can attach the file
+	Backend  string `gorm:"type:text;not null"` // storage.Storage name the file lives in
+	Key      string `gorm:"type:text;not null"`
+	FileName string `gorm:"type:text"`
+	MimeType string `gorm:"type:text;not null"` // sniffed from the content, not the client header
+	Size     int64  `gorm:"not null"`
+
+	CreatedBy string `gorm:"type:text"`
+	DeletedBy string `gorm:"type:text"`
+	CreatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+type UploadResponse struct {
+	ID        string    `json:"id"`
+	FileName  string    `json:"file_name"`
+	MimeType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func UploadURL(id string) string {
+	return "/api/v1/uploads/" + id
+}
+
+func (u *Upload) ToResponse() UploadResponse {
+	return UploadResponse{
+		ID:        u.ID,
+		FileName:  u.FileName,
+		MimeType:  u.MimeType,
+		Size:      u.Size,
+		URL:       UploadURL(u.ID),
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+func ToUploadResponses(uploads []Upload) []UploadResponse {
+	res := make([]UploadResponse, len(uploads))
+	for i, u := range uploads {
+		res[i] = u.ToResponse()
+	}
+	return res
+}
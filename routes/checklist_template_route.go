@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"housing-survey-api/controllers"
+	"housing-survey-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ChecklistTemplateRoutesV1(v1 fiber.Router, ctrl *controllers.ChecklistTemplateController) {
+	checklist := v1.Group("/checklist_template")
+
+	// 🔐 Auth-required routes
+	checklist.Post("", middleware.AdminHandler(ctrl.Create)...)
+	checklist.Put("", middleware.AdminHandler(ctrl.Update)...)
+	checklist.Delete("/:id", middleware.AdminHandler(ctrl.Delete)...)
+	checklist.Get("", middleware.AuthHandler(ctrl.GetAll)...)
+	checklist.Get("/:id", middleware.AuthHandler(ctrl.GetByID)...)
+}
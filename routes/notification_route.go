@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"housing-survey-api/controllers"
+	"housing-survey-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func NotificationRoutesV1(v1 fiber.Router, ctrl *controllers.NotificationController) {
+	notification := v1.Group("/notifications")
+
+	// 🔐 Auth-required routes, every user sees their own notifications
+	notification.Get("", middleware.AuthHandler(ctrl.GetMine)...)
+	notification.Post("/read", middleware.AuthHandler(ctrl.MarkAllRead)...)
+	notification.Post("/:id/read", middleware.AuthHandler(ctrl.MarkRead)...)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"housing-survey-api/controllers"
+	"housing-survey-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ProgramTargetRoutesV1(v1 fiber.Router, ctrl *controllers.ProgramTargetController) {
+	programTarget := v1.Group("/program_target")
+
+	// 🔐 Auth-required routes
+	programTarget.Post("", middleware.AdminHandler(ctrl.Create)...)
+	programTarget.Put("", middleware.AdminHandler(ctrl.Update)...)
+	programTarget.Delete("/:id", middleware.AdminHandler(ctrl.Delete)...)
+	programTarget.Get("", middleware.AuthHandler(ctrl.GetAll)...)
+	programTarget.Get("/achievement", middleware.AuthHandler(ctrl.GetAchievement)...)
+	programTarget.Get("/:id", middleware.AuthHandler(ctrl.GetByID)...)
+}
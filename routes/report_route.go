@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"housing-survey-api/controllers"
+	"housing-survey-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ReportRoutesV1(v1 fiber.Router, ctrl *controllers.ReportController) {
+	report := v1.Group("/reports")
+
+	// 🔐 Auth-required routes, data is scoped to the caller's role
+	report.Get("/monthly", middleware.AuthHandler(ctrl.GetMonthlyReport)...)
+	report.Get("/balai-funnel", middleware.AuthHandler(ctrl.GetBalaiFunnel)...)
+	report.Get("/timeseries", middleware.AuthHandler(ctrl.GetTimeSeries)...)
+	report.Get("/sla-overdue", middleware.AuthHandler(ctrl.GetSLAOverdue)...)
+}
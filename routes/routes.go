@@ -10,6 +10,9 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// LargeBodyPaths are the routes that receive files and check their own body limit
+var LargeBodyPaths = []string{"/api/v1/uploads", "/api/v1/surveys/import"}
+
 func SetupRoutes(app *fiber.App, ctrl *controllers.ControllerRegistry) {
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.SendStatus(fiber.StatusOK)
@@ -21,16 +24,22 @@ func SetupRoutes(app *fiber.App, ctrl *controllers.ControllerRegistry) {
 	UserRoutesV1(v1, ctrl.User)
 	CommentRoutes(v1, ctrl.Comment)
 	SurveyRoutesV1(v1, ctrl.Survey)
+	SurveyAssignmentRoutesV1(v1, ctrl.Assignment)
 	AuditLogRoutes(v1, ctrl.AuditLog)
 	BalaiRoutesV1(v1, ctrl.Balai)
 	DistrictRoutesV1(v1, ctrl.District)
 	ProgramRoutesV1(v1, ctrl.Program)
 	ProgramTypeRoutesV1(v1, ctrl.ProgramType)
+	ProgramTargetRoutesV1(v1, ctrl.Target)
+	ChecklistTemplateRoutesV1(v1, ctrl.Checklist)
 	ProvinceRoutesV1(v1, ctrl.Province)
 	ResourceRoutesV1(v1, ctrl.Resource)
 	RoleRoutesV1(v1, ctrl.Role)
 	SubdistrictRoutesV1(v1, ctrl.Subdistrict)
 	VillageRoutesV1(v1, ctrl.Village)
+	UploadRoutesV1(v1, ctrl.Upload)
+	ReportRoutesV1(v1, ctrl.Report)
+	NotificationRoutesV1(v1, ctrl.Notice)
 }
 
 func PrintRoutes(app *fiber.App) {
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"housing-survey-api/controllers"
+	"housing-survey-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SurveyAssignmentRoutesV1(v1 fiber.Router, ctrl *controllers.SurveyAssignmentController) {
+	assignments := v1.Group("/assignments")
+
+	// 🔐 Auth-required routes
+	assignments.Post("", middleware.AdminBalaiHandler(ctrl.Create)...)
+	assignments.Put("", middleware.AdminBalaiHandler(ctrl.Update)...)
+	assignments.Delete("/:id", middleware.AdminBalaiHandler(ctrl.Delete)...)
+	assignments.Get("", middleware.AuthHandler(ctrl.GetAll)...)
+	assignments.Get("/mine", middleware.SurveyorHandler(ctrl.GetMine)...)
+	assignments.Post("/:id/accept", middleware.SurveyorHandler(ctrl.Accept)...)
+	assignments.Get("/:id", middleware.AuthHandler(ctrl.GetByID)...)
+}
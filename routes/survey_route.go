@@ -13,14 +13,29 @@ func SurveyRoutesV1(v1 fiber.Router, ctrl *controllers.SurveyController) {
 	// 🔐 Auth-required routes
 	survey.Post("", middleware.SurveyorHandler(ctrl.CreateSurvey)...)
 	survey.Put("", middleware.SurveyorHandler(ctrl.UpdateSurvey)...)
+	survey.Post("/import", middleware.With(ctrl.ImportSurveys, middleware.New().UploadBody().Surveyor().Build()...)...)
 	survey.Delete("/:id", middleware.SurveyorHandler(ctrl.DeleteSurvey)...)
+	survey.Post("/:id/resubmit", middleware.SurveyorHandler(ctrl.ResubmitSurvey)...)
+	survey.Post("/:id/correction", middleware.SurveyorHandler(ctrl.RequestCorrection)...)
 	survey.Post("/action", middleware.AuthHandler(ctrl.ActionSurvey)...)
-	// --> add api for infografis balai (survey	by balai->masuk,reject, pending eselon, verif), laporan per bulan,
+	survey.Post("/:id/return", middleware.AuthHandler(ctrl.ReturnSurvey)...)
+	survey.Get("/queue", middleware.AuthHandler(ctrl.GetQueue)...)
+	survey.Post("/:id/claim", middleware.AuthHandler(ctrl.ClaimSurvey)...)
+	survey.Post("/:id/release", middleware.AuthHandler(ctrl.ReleaseSurvey)...)
+	survey.Get("/:id/timeline", middleware.AuthHandler(ctrl.GetSurveyTimeline)...)
+	survey.Get("/:id/notes", middleware.AuthHandler(ctrl.GetSurveyNotes)...)
+	survey.Post("/:id/notes/:note_id/reply", middleware.SurveyorHandler(ctrl.ReplySurveyNote)...)
+	survey.Get("/:id/versions", middleware.AuthHandler(ctrl.GetSurveyVersions)...)
+	survey.Get("/:id/versions/:a/diff/:b", middleware.AuthHandler(ctrl.DiffSurveyVersions)...)
+	// infografis balai and laporan per bulan are served by GET /reports/balai-funnel and /reports/monthly
 	survey.Get("/resource", middleware.AuthHandler(ctrl.GetSurveysByResource)...)
 	survey.Get("/program_type", middleware.AuthHandler(ctrl.GetSurveysByProgramType)...)
 	survey.Get("/verified", middleware.AuthHandler(ctrl.GetSurveysByVerificationStatus)...)
+	survey.Get("/status", middleware.AuthHandler(ctrl.GetSurveysByStatus)...)
+	survey.Get("/export", middleware.AuthHandler(ctrl.ExportSurveys)...)
 
 	// 🌐 PublicAccess routes (no auth)
 	survey.Get("", middleware.PublicHandler(ctrl.GetAllSurveys)...)
+	v1.Get("/surveys.geojson", middleware.PublicHandler(ctrl.ExportGeoJSON)...)
 	survey.Get("/:id", middleware.PublicHandler(ctrl.GetSurveyByID)...)
 }
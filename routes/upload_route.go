@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"housing-survey-api/controllers"
+	"housing-survey-api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func UploadRoutesV1(v1 fiber.Router, ctrl *controllers.UploadController) {
+	upload := v1.Group("/uploads")
+
+	// 🔐 Authenticated routes, so every upload has an owner
+	upload.Post("", middleware.With(ctrl.Upload, middleware.New().UploadBody().Auth().Build()...)...)
+
+	// 🌐 PublicAccess routes (no auth), images of public comments are shown to everyone
+	upload.Get("/:id", middleware.PublicHandler(ctrl.GetFile)...)
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"housing-survey-api/config"
+	"housing-survey-api/internal/context"
+	"housing-survey-api/models"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type ChecklistTemplateService interface {
+	GetAll(ctx *fiber.Ctx) models.ServiceResponse
+	GetByID(ctx *fiber.Ctx, id string) models.ServiceResponse
+	Create(ctx *fiber.Ctx, input *models.ChecklistTemplateInput) models.ServiceResponse
+	Update(ctx *fiber.Ctx, input *models.ChecklistTemplateInput) models.ServiceResponse
+	Delete(ctx *fiber.Ctx, id string) models.ServiceResponse
+}
+
+type checklistTemplateService struct {
+	Db     *gorm.DB
+	Config *config.Config
+}
+
+func NewChecklistTemplateService(ctx *context.AppContext) ChecklistTemplateService {
+	return &checklistTemplateService{
+		Db:     ctx.DB,
+		Config: ctx.Config,
+	}
+}
+
+// ======= SERVICE METHODS =======
+
+// preloadChecklist loads the program type and the items of templates in their order
+func preloadChecklist(db *gorm.DB) *gorm.DB {
+	return db.Preload("ProgramType").Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
+
+// findChecklistTemplate returns the template a verification level fills in for the surveys
+// of a program type, nil when the program type has none for the level
+func findChecklistTemplate(db *gorm.DB, programTypeID uint, level string) (*models.ChecklistTemplate, error) {
+	var templates []models.ChecklistTemplate
+	if err := preloadChecklist(db).Where("program_type_id = ? AND level = ?", programTypeID, level).
+		Limit(1).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	return &templates[0], nil
+}
+
+// GetAll lists the checklist templates, filtered by program_type_id and level
+func (s *checklistTemplateService) GetAll(ctx *fiber.Ctx) models.ServiceResponse {
+	var data []models.ChecklistTemplate
+	db := s.Db.Model(&models.ChecklistTemplate{})
+	if programTypeID := ctx.Query("program_type_id"); programTypeID != "" {
+		db = db.Where("program_type_id = ?", programTypeID)
+	}
+	if level := ctx.Query("level"); level != "" {
+		db = db.Where("level = ?", level)
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to count checklist templates")
+	}
+
+	if err := preloadChecklist(db).Limit(limit).Offset(offset).
+		Order("program_type_id ASC, level ASC, id ASC").Find(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve checklist templates")
+	}
+
+	return models.OkResponse(http.StatusOK, "Success", fiber.Map{
+		"data":       models.ToChecklistTemplateResponses(data),
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+func (s *checklistTemplateService) GetByID(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var data models.ChecklistTemplate
+	if err := preloadChecklist(s.Db).Where("id = ? AND deleted_at IS NULL", id).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Checklist template not found")
+		}
+		return models.InternalServerErrorResponse("Error retrieving checklist template")
+	}
+	return models.OkResponse(http.StatusOK, "Success", data.ToResponse())
+}
+
+// checkTemplateInput makes sure the program type exists and has no other template for the
+// level. It returns false with the response to send when the input is refused.
+func (s *checklistTemplateService) checkTemplateInput(input *models.ChecklistTemplateInput) (models.ServiceResponse, bool) {
+	var count int64
+	if err := s.Db.Model(&models.ProgramType{}).Where("id = ?", input.ProgramTypeID).Count(&count).Error; err != nil {
+		return models.InternalServerErrorResponse("Error checking program type"), false
+	}
+	if count == 0 {
+		return models.BadRequestResponse("Program type not found"), false
+	}
+
+	if err := s.Db.Model(&models.ChecklistTemplate{}).
+		Where("program_type_id = ? AND level = ? AND id <> ?", input.ProgramTypeID, input.Level, input.ID).
+		Count(&count).Error; err != nil {
+		return models.InternalServerErrorResponse("Error checking checklist templates"), false
+	}
+	if count > 0 {
+		return models.ErrResponse(http.StatusConflict, "A checklist for this program type and level already exists"), false
+	}
+	return models.ServiceResponse{}, true
+}
+
+func (s *checklistTemplateService) Create(ctx *fiber.Ctx, input *models.ChecklistTemplateInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	if res, ok := s.checkTemplateInput(input); !ok {
+		return res
+	}
+	data := input.ToModel()
+
+	if err := s.Db.Create(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to create checklist template")
+	}
+	preloadChecklist(s.Db).First(&data, data.ID)
+	utils.LogAudit(ctx, "CREATE_CHECKLIST_TEMPLATE", fmt.Sprintf("Checklist template %d created", data.ID))
+	return models.OkResponse(http.StatusCreated, "Checklist template created", data.ToResponse())
+}
+
+// Update replaces the template and its items. Items sent with their ID are updated in place,
+// those left out are removed and those without an ID are added. Checklists already filled
+// in keep the items they were filled in with.
+func (s *checklistTemplateService) Update(ctx *fiber.Ctx, input *models.ChecklistTemplateInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	var data models.ChecklistTemplate
+	if err := s.Db.Where("id = ? AND deleted_at IS NULL", input.ID).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Checklist template not found")
+		}
+		return models.InternalServerErrorResponse("Error retrieving checklist template")
+	}
+	if res, ok := s.checkTemplateInput(input); !ok {
+		return res
+	}
+
+	var itemIDs []uint
+	if err := s.Db.Model(&models.ChecklistItem{}).Where("template_id = ?", data.ID).Pluck("id", &itemIDs).Error; err != nil {
+		return models.InternalServerErrorResponse("Error retrieving checklist items")
+	}
+	kept := make([]uint, 0, len(input.Items))
+	for _, item := range input.Items {
+		if item.ID == 0 {
+			continue
+		}
+		if !slices.Contains(itemIDs, item.ID) {
+			return models.BadRequestResponse(fmt.Sprintf("Checklist item %d is not part of this template", item.ID))
+		}
+		kept = append(kept, item.ID)
+	}
+
+	data.UpdateFromInput(input)
+	if err := s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Save(&data).Error; err != nil {
+			return err
+		}
+		removed := tx.Where("template_id = ?", data.ID)
+		if len(kept) > 0 {
+			removed = removed.Where("id NOT IN ?", kept)
+		}
+		if err := removed.Delete(&models.ChecklistItem{}).Error; err != nil {
+			return err
+		}
+		for i := range data.Items {
+			data.Items[i].TemplateID = data.ID
+			if err := tx.Save(&data.Items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return models.InternalServerErrorResponse("Failed to update checklist template")
+	}
+	preloadChecklist(s.Db).First(&data, data.ID)
+	utils.LogAudit(ctx, "UPDATE_CHECKLIST_TEMPLATE", fmt.Sprintf("Checklist template %d updated", data.ID))
+	return models.OkResponse(http.StatusOK, "Checklist template updated", data.ToResponse())
+}
+
+func (s *checklistTemplateService) Delete(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var data models.ChecklistTemplate
+	if err := s.Db.Where("id = ? AND deleted_at IS NULL", id).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse(fmt.Sprintf("Checklist template with id %s not found", id))
+		}
+		return models.InternalServerErrorResponse("Error retrieving checklist template")
+	}
+
+	data.MarkDeleted(utils.GetActor(ctx))
+	if err := s.Db.Save(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to delete checklist template")
+	}
+	utils.LogAudit(ctx, "DELETE_CHECKLIST_TEMPLATE", fmt.Sprintf("Checklist template %d deleted", data.ID))
+	return models.OkResponse(http.StatusOK, "Checklist template deleted", nil)
+}
@@ -40,7 +40,8 @@ func NewCommentService(ctx *context.AppContext) CommentService {
 func (s *commentService) GetAllComments(ctx *fiber.Ctx) models.ServiceResponse {
 	var allComments []models.Comment
 
-	db := s.Db.Preload("Survey").Where("deleted_at IS NULL")
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
+	db := s.Db.Preload("Survey").Where("deleted_at IS NULL").Where("survey_id IN (?)", scope.SurveyIDs(s.Db))
 
 	// Filtering
 	if surveyId := ctx.Query("survey"); surveyId != "" {
@@ -133,8 +134,10 @@ func (s *commentService) GetCommentByID(ctx *fiber.Ctx) models.ServiceResponse {
 		return models.BadRequestResponse("Comment ID is required")
 	}
 
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
 	var comment models.Comment
-	if err := s.Db.Preload("Survey").Where("id = ?", id).First(&comment).Error; err != nil {
+	if err := s.Db.Preload("Survey").Where("id = ? AND survey_id IN (?)", id, scope.SurveyIDs(s.Db)).
+		First(&comment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.NotFoundResponse("Comment not found")
 		}
@@ -151,17 +154,17 @@ func (s *commentService) CreatePublicComment(ctx *fiber.Ctx, input models.Commen
 	comment := input.ToComment()
 
 	var survey models.Survey
-	if err := s.Db.Where("id = ?", input.SurveyID).First(&survey).Error; err != nil {
+	if err := newDataScope(ctx, s.Db, s.Config.Roles).Survey(s.Db, input.SurveyID).First(&survey).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.NotFoundResponse("Survey not found")
 		}
 		return models.InternalServerErrorResponse("Failed to find survey")
 	}
 
-	for _, image := range comment.Images {
-		if !utils.IsValidBase64Image(image) {
-			return models.BadRequestResponse("Invalid image format in base64")
-		}
+	// Anonymous commenters have no uploads, only signed-in users can upload
+	userID, _ := utils.GetUserIDFromContext(ctx)
+	if err := validateUploadIDs(s.Db, comment.Images, uint(userID), 0); err != nil {
+		return models.BadRequestResponse(err.Error())
 	}
 
 	if err := s.Db.Create(&comment).Error; err != nil {
@@ -203,9 +206,14 @@ func (s *commentService) UpdateComment(ctx *fiber.Ctx, input models.CommentInput
 		utils.LogAudit(ctx, action, "Inappropriate content detected in comment")
 		return models.BadRequestResponse("Inappropriate content detected.")
 	}
+	if err = validateUploadIDs(s.Db, input.Images, uint(userID), 0); err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.BadRequestResponse(err.Error())
+	}
 
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
 	var comment models.Comment
-	if err = s.Db.Where("id = ?", input.ID).First(&comment).Error; err != nil {
+	if err = s.Db.Where("id = ? AND survey_id IN (?)", input.ID, scope.SurveyIDs(s.Db)).First(&comment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.LogAudit(ctx, action, err.Error())
 			return models.NotFoundResponse("Comment not found")
@@ -238,8 +246,9 @@ func (s *commentService) DeleteComment(ctx *fiber.Ctx, id string) models.Service
 		return models.UnauthorizedResponse("User not authenticated")
 	}
 
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
 	var comment models.Comment
-	if err := s.Db.Where("id = ?", id).First(&comment).Error; err != nil {
+	if err := s.Db.Where("id = ? AND survey_id IN (?)", id, scope.SurveyIDs(s.Db)).First(&comment).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.LogAudit(ctx, action, err.Error())
 			return models.NotFoundResponse("Comment not found")
@@ -283,8 +292,9 @@ func (s *commentService) ActionComment(ctx *fiber.Ctx, input models.CommentActio
 		return models.UnauthorizedResponse("User role not found")
 	}
 
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
 	var root models.Comment
-	if err := s.Db.Where("id = ?", input.CommentID).First(&root).Error; err != nil {
+	if err := s.Db.Where("id = ? AND survey_id IN (?)", input.CommentID, scope.SurveyIDs(s.Db)).First(&root).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			utils.LogAudit(ctx, actionTag, err.Error())
 			return models.NotFoundResponse("Comment not found")
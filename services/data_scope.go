@@ -0,0 +1,127 @@
+package services
+
+import (
+	"housing-survey-api/config"
+	"housing-survey-api/models"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Reach of a DataScope
+const (
+	scopeAll   = iota // every survey: Eselon 1, super admin and public readers
+	scopeOwn          // the caller's own surveys: surveyors
+	scopeBalai        // surveys made by surveyors of the caller's Balai: Balai roles
+	scopeNone         // nothing: Balai roles without a Balai, or a caller that cannot be resolved
+)
+
+// DataScope is the set of surveys the caller may read, change or act on. It is resolved
+// once per request from the caller's role and profile; every survey query of the request
+// goes through it, as do the records hanging off surveys (comments, assignments).
+type DataScope struct {
+	Role    string
+	UserID  uint
+	BalaiID uint // Balai of the profile of surveyors and Balai roles, 0 without one
+	reach   int
+}
+
+// newDataScope resolves the scope of the caller. Requests without a token get the public
+// scope; a Balai role whose profile has no Balai sees nothing rather than everything.
+func newDataScope(ctx *fiber.Ctx, conn *gorm.DB, roles config.RolesConfig) *DataScope {
+	d := &DataScope{Role: roles.Public, reach: scopeAll}
+	if role, err := utils.GetRoleNameFromContext(ctx); err == nil {
+		d.Role = role
+	}
+	if id, err := utils.GetUserIDFromContext(ctx); err == nil {
+		d.UserID = uint(id)
+	}
+
+	switch d.Role {
+	case roles.Surveyor, roles.VerificatorBalai, roles.AdminBalai:
+		var profile models.Profile
+		if d.UserID != 0 {
+			if err := conn.Where("user_id = ?", d.UserID).Limit(1).Find(&profile).Error; err == nil && profile.BalaiID != nil {
+				d.BalaiID = *profile.BalaiID
+			}
+		}
+		switch {
+		case d.UserID == 0:
+			d.reach = scopeNone
+		case d.Role == roles.Surveyor:
+			d.reach = scopeOwn
+		case d.BalaiID != 0:
+			d.reach = scopeBalai
+		default:
+			d.reach = scopeNone
+		}
+	}
+	return d
+}
+
+// dataScope resolves the scope of the caller of a survey service request
+func (s *surveyService) dataScope(ctx *fiber.Ctx) *DataScope {
+	return newDataScope(ctx, s.Db, s.Config.Roles)
+}
+
+// Surveys limits a query over surveys, or over a table aliased as surveys with a user_id
+// column such as survey_statistics, to the surveys of the scope
+func (d *DataScope) Surveys(db *gorm.DB) *gorm.DB {
+	switch d.reach {
+	case scopeOwn:
+		return db.Where("surveys.user_id = ?", d.UserID)
+	case scopeBalai:
+		// A subquery instead of a join leaves the profiles table free for the caller's joins
+		// and keeps row locks taken through the scope on surveys alone
+		return db.Where("EXISTS (SELECT 1 FROM profiles scope_profiles WHERE scope_profiles.user_id = surveys.user_id "+
+			"AND scope_profiles.balai_id = ? AND scope_profiles.deleted_at IS NULL)", d.BalaiID)
+	case scopeNone:
+		return db.Where("1 = 0")
+	}
+	return db
+}
+
+// Survey returns a query for one survey of the scope; a survey outside it is not found
+func (d *DataScope) Survey(db *gorm.DB, id interface{}) *gorm.DB {
+	return d.Surveys(db.Model(&models.Survey{})).Where("surveys.id = ?", id)
+}
+
+// CanSee reports whether the survey is within the scope
+func (d *DataScope) CanSee(db *gorm.DB, surveyID interface{}) (bool, error) {
+	var count int64
+	if err := d.Survey(db, surveyID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SurveyIDs is a subquery of the IDs of the surveys of the scope, for records hanging off surveys
+func (d *DataScope) SurveyIDs(db *gorm.DB) *gorm.DB {
+	return d.Surveys(db.Session(&gorm.Session{NewDB: true}).Model(&models.Survey{})).Select("surveys.id")
+}
+
+// Assignments limits a query over survey_assignments: surveyors see those given to them,
+// Balai roles those of their Balai
+func (d *DataScope) Assignments(db *gorm.DB) *gorm.DB {
+	switch d.reach {
+	case scopeOwn:
+		return db.Where("survey_assignments.assignee_id = ?", d.UserID)
+	case scopeBalai:
+		return db.Where("survey_assignments.balai_id = ?", d.BalaiID)
+	case scopeNone:
+		return db.Where("1 = 0")
+	}
+	return db
+}
+
+// Balai returns the Balai the scope is tied to and true for surveyors and Balai roles,
+// false for scopes spanning every Balai. The Balai is 0 when the caller's profile has none.
+func (d *DataScope) Balai() (uint, bool) {
+	return d.BalaiID, d.reach != scopeAll
+}
+
+// IsOwn reports whether the scope only holds the caller's own surveys
+func (d *DataScope) IsOwn() bool {
+	return d.reach == scopeOwn
+}
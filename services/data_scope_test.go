@@ -0,0 +1,131 @@
+package services
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"housing-survey-api/config"
+	"housing-survey-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+var scopeRoles = config.RolesConfig{
+	SuperAdmin:         "Super Admin",
+	AdminEselon1:       "Admin Eselon 1",
+	VerificatorEselon1: "Verificator Eselon 1",
+	AdminBalai:         "Admin Balai",
+	VerificatorBalai:   "Verificator Balai",
+	Surveyor:           "Surveyor",
+	Public:             "Public",
+}
+
+// profileDB is a dry-run connection answering profile lookups from balaiOf, user ID to Balai ID
+func profileDB(t *testing.T, balaiOf map[uint]uint) *gorm.DB {
+	db := dryRunDB(t)
+	err := db.Callback().Query().Replace("gorm:query", func(db *gorm.DB) {
+		callbacks.BuildQuerySQL(db)
+		profile, ok := db.Statement.Dest.(*models.Profile)
+		if !ok || len(db.Statement.Vars) == 0 {
+			return
+		}
+		userID, _ := db.Statement.Vars[0].(uint)
+		if balaiID, ok := balaiOf[userID]; ok {
+			profile.UserID, profile.BalaiID = userID, &balaiID
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// resolveScope resolves the DataScope of a request carrying the claims, none for a public request
+func resolveScope(t *testing.T, conn *gorm.DB, claims jwt.MapClaims) *DataScope {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	if claims != nil {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("JWT_SECRET")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	var scope *DataScope
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		scope = newDataScope(c, conn, scopeRoles)
+		return nil
+	})
+	if _, err := app.Test(req); err != nil {
+		t.Fatal(err)
+	}
+	return scope
+}
+
+func TestDataScopeReach(t *testing.T) {
+	conn := profileDB(t, map[uint]uint{5: 3, 6: 3})
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		reach   int
+		balaiID uint
+		where   string // condition the scope adds to a survey query, empty for none
+	}{
+		{name: "public", reach: scopeAll},
+		{name: "eselon 1", claims: jwt.MapClaims{"user_id": 1, "role_name": "Verificator Eselon 1"}, reach: scopeAll},
+		{name: "super admin", claims: jwt.MapClaims{"user_id": 2, "role_name": "Super Admin"}, reach: scopeAll},
+		{name: "surveyor", claims: jwt.MapClaims{"user_id": 5, "role_name": "Surveyor"}, reach: scopeOwn, balaiID: 3, where: "surveys.user_id = $1"},
+		{name: "verificator balai", claims: jwt.MapClaims{"user_id": 6, "role_name": "Verificator Balai"}, reach: scopeBalai, balaiID: 3, where: "scope_profiles.balai_id = $1"},
+		{name: "admin balai without balai", claims: jwt.MapClaims{"user_id": 7, "role_name": "Admin Balai"}, reach: scopeNone, where: "1 = 0"},
+		{name: "balai role without user", claims: jwt.MapClaims{"role_name": "Verificator Balai"}, reach: scopeNone, where: "1 = 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := resolveScope(t, conn, tt.claims)
+			if d.reach != tt.reach || d.BalaiID != tt.balaiID {
+				t.Fatalf("reach %d balai %d, want %d balai %d", d.reach, d.BalaiID, tt.reach, tt.balaiID)
+			}
+
+			var surveys []models.Survey
+			sql := d.Surveys(dryRunDB(t).Model(&models.Survey{})).Find(&surveys).Statement.SQL.String()
+			if tt.where == "" && strings.Contains(sql, " AND ") {
+				t.Errorf("query over every survey narrowed: %s", sql)
+			}
+			if tt.where != "" && !strings.Contains(sql, tt.where) {
+				t.Errorf("query %s does not contain %s", sql, tt.where)
+			}
+
+			if _, tied := d.Balai(); tied != (tt.reach != scopeAll) {
+				t.Errorf("Balai() tied = %v", tied)
+			}
+			if d.IsOwn() != (tt.reach == scopeOwn) {
+				t.Errorf("IsOwn() = %v", d.IsOwn())
+			}
+		})
+	}
+}
+
+func TestDataScopeAssignments(t *testing.T) {
+	tests := []struct {
+		scope DataScope
+		where string
+	}{
+		{DataScope{UserID: 5, reach: scopeOwn}, "survey_assignments.assignee_id = $1"},
+		{DataScope{BalaiID: 3, reach: scopeBalai}, "survey_assignments.balai_id = $1"},
+		{DataScope{reach: scopeNone}, "1 = 0"},
+	}
+	for _, tt := range tests {
+		var assignments []models.SurveyAssignment
+		sql := tt.scope.Assignments(dryRunDB(t).Model(&models.SurveyAssignment{})).Find(&assignments).Statement.SQL.String()
+		if !strings.Contains(sql, tt.where) {
+			t.Errorf("reach %d: %s does not contain %s", tt.scope.reach, sql, tt.where)
+		}
+	}
+}
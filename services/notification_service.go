@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/internal/context"
+	"housing-survey-api/models"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type NotificationService interface {
+	GetMine(ctx *fiber.Ctx) models.ServiceResponse
+	MarkRead(ctx *fiber.Ctx, id string) models.ServiceResponse
+	MarkAllRead(ctx *fiber.Ctx) models.ServiceResponse
+}
+
+type notificationService struct {
+	Db     *gorm.DB
+	Config *config.Config
+}
+
+func NewNotificationService(ctx *context.AppContext) NotificationService {
+	return &notificationService{
+		Db:     ctx.DB,
+		Config: ctx.Config,
+	}
+}
+
+// GetMine lists the caller's notifications, newest first; unread=true keeps the unread ones
+func (s *notificationService) GetMine(ctx *fiber.Ctx) models.ServiceResponse {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
+	}
+	db := s.Db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if ctx.QueryBool("unread", false) {
+		db = db.Where("read_at IS NULL")
+	}
+	if types := utils.SplitAndTrim(ctx.Query("types"), ","); len(types) > 0 {
+		db = db.Where("type IN ?", types)
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	var total, unread int64
+	if err := db.Count(&total).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to count notifications")
+	}
+	if err := s.Db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&unread).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to count notifications")
+	}
+
+	var data []models.Notification
+	if err := db.Limit(limit).Offset(offset).Order("created_at DESC, id DESC").Find(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve notifications")
+	}
+
+	return models.OkResponse(http.StatusOK, "Success", fiber.Map{
+		"data":       models.ToNotificationResponses(data),
+		"unread":     unread,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+func (s *notificationService) MarkRead(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
+	}
+	var data models.Notification
+	if err := s.Db.Where("id = ? AND user_id = ?", id, userID).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Notification not found")
+		}
+		return models.InternalServerErrorResponse("Error retrieving notification")
+	}
+	if data.ReadAt == nil {
+		now := time.Now()
+		data.ReadAt = &now
+		if err := s.Db.Model(&data).Update("read_at", now).Error; err != nil {
+			return models.InternalServerErrorResponse("Failed to update notification")
+		}
+	}
+	return models.OkResponse(http.StatusOK, "Notification read", data.ToResponse())
+}
+
+func (s *notificationService) MarkAllRead(ctx *fiber.Ctx) models.ServiceResponse {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
+	}
+	res := s.Db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		return models.InternalServerErrorResponse("Failed to update notifications")
+	}
+	return models.OkResponse(http.StatusOK, "Notifications read", fiber.Map{"updated": res.RowsAffected})
+}
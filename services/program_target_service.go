@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/internal/context"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type ProgramTargetService interface {
+	GetAll(ctx *fiber.Ctx) models.ServiceResponse
+	GetByID(ctx *fiber.Ctx, id string) models.ServiceResponse
+	Create(ctx *fiber.Ctx, input *models.ProgramTargetInput) models.ServiceResponse
+	Update(ctx *fiber.Ctx, input *models.ProgramTargetInput) models.ServiceResponse
+	Delete(ctx *fiber.Ctx, id string) models.ServiceResponse
+	GetAchievement(ctx *fiber.Ctx) models.ServiceResponse
+}
+
+type programTargetService struct {
+	Db     *gorm.DB
+	Config *config.Config
+}
+
+func NewProgramTargetService(ctx *context.AppContext) ProgramTargetService {
+	return &programTargetService{
+		Db:     ctx.DB,
+		Config: ctx.Config,
+	}
+}
+
+// ======= SERVICE METHODS =======
+
+// filterTargets applies the program_ids, province_ids and balai_ids filters
+func filterTargets(ctx *fiber.Ctx, db *gorm.DB) *gorm.DB {
+	if programIDs := utils.SplitAndTrim(ctx.Query("program_ids"), ","); len(programIDs) > 0 {
+		db = db.Where("program_targets.program_id IN ?", programIDs)
+	}
+	if provinceIDs := utils.SplitAndTrim(ctx.Query("province_ids"), ","); len(provinceIDs) > 0 {
+		db = db.Where("program_targets.province_id IN ?", provinceIDs)
+	}
+	if balaiIDs := utils.SplitAndTrim(ctx.Query("balai_ids"), ","); len(balaiIDs) > 0 {
+		db = db.Where("program_targets.balai_id IN ?", balaiIDs)
+	}
+	return db
+}
+
+func (s *programTargetService) GetAll(ctx *fiber.Ctx) models.ServiceResponse {
+	var data []models.ProgramTarget
+	db := filterTargets(ctx, s.Db.Model(&models.ProgramTarget{}))
+	if year := ctx.Query("year"); year != "" {
+		db = db.Where("program_targets.year = ?", year)
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to count program targets")
+	}
+
+	if err := db.Preload("Program").Preload("Province").Preload("Balai").
+		Limit(limit).Offset(offset).Order("year DESC, program_id ASC, id ASC").Find(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve program targets")
+	}
+
+	return models.OkResponse(http.StatusOK, "Success", fiber.Map{
+		"data":       models.ToProgramTargetResponses(data),
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+func (s *programTargetService) GetByID(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var data models.ProgramTarget
+	if err := s.Db.Preload("Program").Preload("Province").Preload("Balai").
+		Where("id = ? AND deleted_at IS NULL", id).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Program target not found")
+		}
+		return models.InternalServerErrorResponse("Error retrieving program target")
+	}
+	return models.OkResponse(http.StatusOK, "Success", data.ToResponse())
+}
+
+// checkTargetInput makes sure the referenced program, province and Balai exist and that
+// no other target covers the same program, year, province and Balai. It returns false
+// with the response to send when the input is refused.
+func (s *programTargetService) checkTargetInput(input *models.ProgramTargetInput) (models.ServiceResponse, bool) {
+	exists := func(model interface{}, id uint) bool {
+		var count int64
+		s.Db.Model(model).Where("id = ?", id).Count(&count)
+		return count > 0
+	}
+	if !exists(&models.Program{}, input.ProgramID) {
+		return models.BadRequestResponse("Program not found"), false
+	}
+	if input.ProvinceID != nil && !exists(&models.Province{}, *input.ProvinceID) {
+		return models.BadRequestResponse("Province not found"), false
+	}
+	if input.BalaiID != nil && !exists(&models.Balai{}, *input.BalaiID) {
+		return models.BadRequestResponse("Balai not found"), false
+	}
+
+	db := s.Db.Model(&models.ProgramTarget{}).
+		Where("program_id = ? AND year = ? AND id <> ?", input.ProgramID, input.Year, input.ID)
+	if input.ProvinceID != nil {
+		db = db.Where("province_id = ?", *input.ProvinceID)
+	} else {
+		db = db.Where("province_id IS NULL")
+	}
+	if input.BalaiID != nil {
+		db = db.Where("balai_id = ?", *input.BalaiID)
+	} else {
+		db = db.Where("balai_id IS NULL")
+	}
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return models.InternalServerErrorResponse("Error checking program targets"), false
+	}
+	if count > 0 {
+		return duplicateTargetResponse(), false
+	}
+	return models.ServiceResponse{}, true
+}
+
+func duplicateTargetResponse() models.ServiceResponse {
+	return models.ErrResponse(http.StatusConflict, "A target for this program, year and region already exists")
+}
+
+// isDuplicateTarget reports whether err is the unique index refusing a target saved
+// concurrently with another one for the same program, year and region
+func isDuplicateTarget(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == models.ProgramTargetScopeIndex
+}
+
+func (s *programTargetService) Create(ctx *fiber.Ctx, input *models.ProgramTargetInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	if res, ok := s.checkTargetInput(input); !ok {
+		return res
+	}
+	data := input.ToModel()
+
+	if err := s.Db.Create(&data).Error; err != nil {
+		if isDuplicateTarget(err) {
+			return duplicateTargetResponse()
+		}
+		return models.InternalServerErrorResponse("Failed to create program target")
+	}
+	s.Db.Preload("Program").Preload("Province").Preload("Balai").First(&data, data.ID)
+	utils.LogAudit(ctx, "CREATE_PROGRAM_TARGET", fmt.Sprintf("Program target %d created", data.ID))
+	return models.OkResponse(http.StatusCreated, "Program target created", data.ToResponse())
+}
+
+func (s *programTargetService) Update(ctx *fiber.Ctx, input *models.ProgramTargetInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	var data models.ProgramTarget
+	if err := s.Db.Where("id = ? AND deleted_at IS NULL", input.ID).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Program target not found")
+		}
+		return models.InternalServerErrorResponse("Error retrieving program target")
+	}
+	if res, ok := s.checkTargetInput(input); !ok {
+		return res
+	}
+
+	data.UpdateFromInput(input)
+
+	if err := s.Db.Save(&data).Error; err != nil {
+		if isDuplicateTarget(err) {
+			return duplicateTargetResponse()
+		}
+		return models.InternalServerErrorResponse("Failed to update program target")
+	}
+	s.Db.Preload("Program").Preload("Province").Preload("Balai").First(&data, data.ID)
+	utils.LogAudit(ctx, "UPDATE_PROGRAM_TARGET", fmt.Sprintf("Program target %d updated", data.ID))
+	return models.OkResponse(http.StatusOK, "Program target updated", data.ToResponse())
+}
+
+func (s *programTargetService) Delete(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var data models.ProgramTarget
+	if err := s.Db.Where("id = ? AND deleted_at IS NULL", id).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse(fmt.Sprintf("Program target with id %s not found", id))
+		}
+		return models.InternalServerErrorResponse("Error retrieving program target")
+	}
+
+	data.MarkDeleted(utils.GetActor(ctx))
+	if err := s.Db.Save(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to delete program target")
+	}
+	utils.LogAudit(ctx, "DELETE_PROGRAM_TARGET", fmt.Sprintf("Program target %d deleted", data.ID))
+	return models.OkResponse(http.StatusOK, "Program target deleted", nil)
+}
+
+// achievementPercent rounds realized/target to one decimal, nil without a target
+func achievementPercent(realized, target uint64) *float64 {
+	if target == 0 {
+		return nil
+	}
+	percent := math.Round(float64(realized)/float64(target)*1000) / 10
+	return &percent
+}
+
+// GetAchievement compares every target of a year (default the current year) with the
+// surveys of its program verified by Eselon 1 and realized ("Selesai") in that year.
+// A national target counts all of them, a province target those in its province and a
+// Balai target those made by its surveyors. Targets are national figures, so the caller's
+// role does not narrow the surveys counted.
+func (s *programTargetService) GetAchievement(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "PROGRAM_ACHIEVEMENT"
+	year := ctx.QueryInt("year", time.Now().Year())
+	if year < 2000 || year > 2100 {
+		return models.BadRequestResponse("year must be between 2000 and 2100")
+	}
+
+	var targets []models.ProgramTarget
+	if err := filterTargets(ctx, s.Db.Model(&models.ProgramTarget{})).
+		Where("program_targets.year = ?", year).
+		Preload("Program").Preload("Province").Preload("Balai").
+		Order("program_id ASC, province_id ASC NULLS FIRST, balai_id ASC NULLS FIRST").
+		Find(&targets).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to retrieve program targets")
+	}
+	result := make([]models.ProgramAchievement, len(targets))
+	if len(targets) == 0 {
+		return models.OkResponse(http.StatusOK, "Success", result)
+	}
+
+	programIDs := make([]uint, 0, len(targets))
+	for _, t := range targets {
+		programIDs = append(programIDs, t.ProgramID)
+	}
+
+	src, err := newAggregateSource(ctx, s.Db, s.Config)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	realizedYear, _ := src.realizedSQL()
+	var rows []models.AchievementRow
+	if err := src.unscoped().
+		Select("surveys.program_id AS program_id, surveys.province_id AS province_id, "+
+			"COALESCE(owner_profiles.balai_id, 0) AS balai_id, "+src.countSQL()+" AS survey_count, "+
+			"COALESCE(SUM(surveys.unit_target), 0) AS units, COALESCE(SUM(surveys.budget), 0) AS budget").
+		Joins("LEFT JOIN profiles owner_profiles ON owner_profiles.user_id = surveys.user_id AND owner_profiles.deleted_at IS NULL").
+		Where(src.statusSQL()+" = ?", shared.StatusVerified).
+		Where(realizedYear+" = ?", year).
+		Where("surveys.program_id IN ?", programIDs).
+		Group("1, 2, 3").
+		Scan(&rows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to sum realized surveys")
+	}
+
+	for i, t := range targets {
+		a := models.ProgramAchievement{ProgramTargetResponse: t.ToResponse()}
+		for _, row := range rows {
+			if row.ProgramID != t.ProgramID ||
+				(t.ProvinceID != nil && row.ProvinceID != *t.ProvinceID) ||
+				(t.BalaiID != nil && row.BalaiID != *t.BalaiID) {
+				continue
+			}
+			a.Surveys += row.Surveys
+			a.RealizedUnits += row.Units
+			a.RealizedBudget += row.Budget
+		}
+		a.UnitPercent = achievementPercent(a.RealizedUnits, t.UnitTarget)
+		a.BudgetPercent = achievementPercent(a.RealizedBudget, t.BudgetTarget)
+		result[i] = a
+	}
+
+	return models.OkResponse(http.StatusOK, "Success", result).WithRefreshedAt(src.refreshedAt)
+}
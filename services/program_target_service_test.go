@@ -0,0 +1,21 @@
+package services
+
+import "testing"
+
+func TestAchievementPercent(t *testing.T) {
+	if p := achievementPercent(50, 0); p != nil {
+		t.Errorf("without a target: %v, want nil", *p)
+	}
+	tests := map[[2]uint64]float64{
+		{0, 200}:   0,
+		{50, 200}:  25,
+		{1, 3}:     33.3,
+		{300, 200}: 150, // targets can be exceeded
+	}
+	for in, want := range tests {
+		p := achievementPercent(in[0], in[1])
+		if p == nil || *p != want {
+			t.Errorf("achievementPercent(%d, %d) = %v, want %v", in[0], in[1], p, want)
+		}
+	}
+}
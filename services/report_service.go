@@ -0,0 +1,273 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/internal/context"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxReportYears bounds the year range of a report
+const maxReportYears = 10
+
+type ReportService interface {
+	GetMonthlyReport(ctx *fiber.Ctx) models.ServiceResponse
+	GetBalaiFunnel(ctx *fiber.Ctx) models.ServiceResponse
+	GetTimeSeries(ctx *fiber.Ctx) models.ServiceResponse
+	GetSLAOverdue(ctx *fiber.Ctx) models.ServiceResponse
+}
+
+type reportService struct {
+	Db     *gorm.DB
+	Config *config.Config
+}
+
+func NewReportService(ctx *context.AppContext) ReportService {
+	return &reportService{
+		Db:     ctx.DB,
+		Config: ctx.Config,
+	}
+}
+
+// parseYearRange reads year_from and year_to, both defaulting to the current year
+func parseYearRange(ctx *fiber.Ctx) (int, int, error) {
+	now := time.Now().Year()
+	from := ctx.QueryInt("year_from", now)
+	to := ctx.QueryInt("year_to", from)
+	if from < 2000 || to > now+5 {
+		return 0, 0, fmt.Errorf("years must be between 2000 and %d", now+5)
+	}
+	if from > to {
+		return 0, 0, errors.New("year_from must not be after year_to")
+	}
+	if to-from >= maxReportYears {
+		return 0, 0, fmt.Errorf("a report covers at most %d years", maxReportYears)
+	}
+	return from, to, nil
+}
+
+// GetMonthlyReport sums the units and budget of the surveys realized ("Selesai") in each
+// month of the year range, by program type, resource tag and province. Drafts are left out;
+// verified_only=true keeps only surveys verified by Eselon 1. The survey list filters apply.
+// It reads the precomputed statistics when it can, see newAggregateSource.
+func (s *reportService) GetMonthlyReport(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "REPORT_MONTHLY"
+	from, to, err := parseYearRange(ctx)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	verifiedOnly := ctx.QueryBool("verified_only", false)
+
+	src, err := newAggregateSource(ctx, s.Db, s.Config)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	yearSQL, monthSQL := src.realizedSQL()
+	base := func() *gorm.DB {
+		db := src.query().
+			Where(src.statusSQL()+" <> ?", shared.StatusDraft).
+			Where(yearSQL+" BETWEEN ? AND ?", from, to).
+			Where(monthSQL + " BETWEEN 1 AND 12")
+		if verifiedOnly {
+			db = db.Where(src.statusSQL()+" = ?", shared.StatusVerified)
+		}
+		return db
+	}
+
+	sums := yearSQL + " AS year, " + monthSQL + " AS month, " + src.countSQL() + " AS survey_count, " +
+		"COALESCE(SUM(surveys.unit_target), 0) AS units, COALESCE(SUM(surveys.budget), 0) AS budget"
+	groupings := []struct {
+		selects string
+		joins   string
+		groupBy string
+	}{
+		{"", "", ""},
+		{"COALESCE(program_types.id, 0) AS id, COALESCE(program_types.name, '') AS name",
+			"LEFT JOIN program_types ON program_types.id = surveys.program_type_id",
+			", program_types.id, program_types.name"},
+		{"COALESCE(resources.tag, '') AS name",
+			"LEFT JOIN resources ON resources.id = surveys.resource_id",
+			", resources.tag"},
+		{"COALESCE(provinces.id, 0) AS id, COALESCE(provinces.name, '') AS name",
+			"LEFT JOIN provinces ON provinces.id = surveys.province_id",
+			", provinces.id, provinces.name"},
+	}
+	results := make([][]models.ReportGroupRow, len(groupings))
+	for i, g := range groupings {
+		db := base()
+		selects := sums
+		if g.selects != "" {
+			selects += ", " + g.selects
+			db = db.Joins(g.joins)
+		}
+		if err := db.Select(selects).
+			Group("1, 2" + g.groupBy).
+			Order("year, month, survey_count DESC").
+			Scan(&results[i]).Error; err != nil {
+			utils.LogAudit(ctx, action, err.Error())
+			return models.InternalServerErrorResponse("Failed to build monthly report")
+		}
+	}
+
+	report := models.MonthlyReport{YearFrom: from, YearTo: to, VerifiedOnly: verifiedOnly, Total: models.ReportBreakdown{Name: "Total"}}
+	index := make(map[[2]int]*models.MonthlyReportItem)
+	for year := from; year <= to; year++ {
+		for month := 1; month <= 12; month++ {
+			report.Months = append(report.Months, models.MonthlyReportItem{
+				Year:          year,
+				Month:         month,
+				ByProgramType: []models.ReportBreakdown{},
+				ByResourceTag: []models.ReportBreakdown{},
+				ByProvince:    []models.ReportBreakdown{},
+			})
+		}
+	}
+	for i := range report.Months {
+		m := &report.Months[i]
+		index[[2]int{m.Year, m.Month}] = m
+	}
+
+	for _, row := range results[0] {
+		if m := index[[2]int{row.Year, row.Month}]; m != nil {
+			m.Surveys, m.Units, m.Budget = row.Surveys, row.Units, row.Budget
+			report.Total.Surveys += row.Surveys
+			report.Total.Units += row.Units
+			report.Total.Budget += row.Budget
+		}
+	}
+	for _, row := range results[1] {
+		if m := index[[2]int{row.Year, row.Month}]; m != nil {
+			m.ByProgramType = append(m.ByProgramType, row.ToBreakdown())
+		}
+	}
+	for _, row := range results[2] {
+		if m := index[[2]int{row.Year, row.Month}]; m != nil {
+			m.ByResourceTag = append(m.ByResourceTag, row.ToBreakdown())
+		}
+	}
+	for _, row := range results[3] {
+		if m := index[[2]int{row.Year, row.Month}]; m != nil {
+			m.ByProvince = append(m.ByProvince, row.ToBreakdown())
+		}
+	}
+
+	return models.OkResponse(fiber.StatusOK, "Monthly report retrieved successfully", report).WithRefreshedAt(src.refreshedAt)
+}
+
+// stageDurationSQL averages how long surveys stayed in each status before leaving it,
+// from the time between consecutive status history entries of a survey
+const stageDurationSQL = `SELECT profiles.balai_id AS balai_id, h.from_status AS status, COUNT(*) AS count,
+	AVG(EXTRACT(EPOCH FROM (h.created_at - h.entered_at))) / 3600 AS avg_hours
+FROM (
+	SELECT survey_id, from_status, created_at,
+		LAG(created_at) OVER (PARTITION BY survey_id ORDER BY created_at, id) AS entered_at
+	FROM survey_status_history
+) h
+JOIN surveys ON surveys.id = h.survey_id AND surveys.deleted_at IS NULL
+JOIN profiles ON profiles.user_id = surveys.user_id AND profiles.deleted_at IS NULL
+WHERE h.entered_at IS NOT NULL AND h.from_status <> '' AND profiles.balai_id IN @balai_ids
+	AND (@user_id = 0 OR surveys.user_id = @user_id)
+GROUP BY 1, 2`
+
+// GetBalaiFunnel returns the verification funnel of every Balai, based on the Balai of
+// each survey's surveyor. Balai roles only get their own Balai, surveyors their own
+// surveys within their Balai. Others may pick one Balai with balai_id. The stage counts
+// follow the survey list filters and read the precomputed statistics when they can; stage
+// durations always come from the status history.
+func (s *reportService) GetBalaiFunnel(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "REPORT_BALAI_FUNNEL"
+	src, err := newAggregateSource(ctx, s.Db, s.Config)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
+	balaiQuery := s.Db.Model(&models.Balai{}).Order("name")
+	if balaiID, bound := scope.Balai(); bound {
+		if balaiID == 0 {
+			return models.ForbiddenResponse("Your profile is not assigned to a Balai")
+		}
+		balaiQuery = balaiQuery.Where("id = ?", balaiID)
+	} else if balaiID := ctx.QueryInt("balai_id", 0); balaiID > 0 {
+		balaiQuery = balaiQuery.Where("id = ?", balaiID)
+	}
+
+	var balais []models.Balai
+	if err := balaiQuery.Find(&balais).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Error retrieving balai")
+	}
+	if len(balais) == 0 {
+		return models.NotFoundResponse("Balai not found")
+	}
+	balaiIDs := make([]uint, len(balais))
+	for i, b := range balais {
+		balaiIDs[i] = b.ID
+	}
+
+	var counts []models.BalaiStatusRow
+	countQuery := src.query().
+		Select("profiles.balai_id AS balai_id, "+src.statusSQL()+" AS status, "+src.countSQL()+" AS count").
+		Joins("JOIN profiles ON profiles.user_id = surveys.user_id AND profiles.deleted_at IS NULL").
+		Where("profiles.balai_id IN ?", balaiIDs)
+	if err := countQuery.Group("1, 2").Scan(&counts).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count surveys by balai")
+	}
+
+	ownerID := uint(0) // the raw duration query cannot take the scope, it limits surveyors to their surveys
+	if scope.IsOwn() {
+		ownerID = scope.UserID
+	}
+	var durations []models.BalaiStatusRow
+	if err := s.Db.Raw(stageDurationSQL, map[string]interface{}{
+		"balai_ids": balaiIDs,
+		"user_id":   ownerID,
+	}).Scan(&durations).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to compute stage durations")
+	}
+
+	type key struct {
+		balai  uint
+		status string
+	}
+	countOf := make(map[key]int64, len(counts))
+	for _, c := range counts {
+		countOf[key{c.BalaiID, c.Status}] = c.Count
+	}
+	hoursOf := make(map[key]float64, len(durations))
+	for _, d := range durations {
+		hoursOf[key{d.BalaiID, d.Status}] = math.Round(d.AvgHours*10) / 10
+	}
+
+	result := make([]models.BalaiFunnel, len(balais))
+	for i, b := range balais {
+		funnel := models.BalaiFunnel{BalaiID: b.ID, BalaiName: b.Name, Stages: make([]models.FunnelStage, len(models.SurveyStatuses))}
+		for j, status := range models.SurveyStatuses {
+			k := key{b.ID, status}
+			stage := models.FunnelStage{Status: status, Count: countOf[k]}
+			if hours, ok := hoursOf[k]; ok {
+				stage.AvgHours = &hours
+			}
+			funnel.Stages[j] = stage
+			if status != shared.StatusDraft {
+				funnel.Received += stage.Count
+			}
+		}
+		funnel.Rejected = countOf[key{b.ID, shared.StatusRejectedBalai}] + countOf[key{b.ID, shared.StatusRejectedEselon1}]
+		funnel.PendingEselon1 = countOf[key{b.ID, shared.StatusWaitingEselon1}]
+		funnel.Verified = countOf[key{b.ID, shared.StatusVerified}]
+		result[i] = funnel
+	}
+
+	return models.OkResponse(fiber.StatusOK, "Balai funnel retrieved successfully", result).WithRefreshedAt(src.refreshedAt)
+}
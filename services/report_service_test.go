@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseYearRange(t *testing.T) {
+	now := time.Now().Year()
+	tests := []struct {
+		query    string
+		from, to int
+		wantErr  bool
+	}{
+		{query: "", from: now, to: now},
+		{query: "year_from=2021", from: 2021, to: 2021},
+		{query: "year_from=2020&year_to=2024", from: 2020, to: 2024},
+		{query: fmt.Sprintf("year_from=%d&year_to=%d", now-9, now), from: now - 9, to: now},
+		{query: fmt.Sprintf("year_from=%d&year_to=%d", now-10, now), wantErr: true},
+		{query: "year_from=2024&year_to=2020", wantErr: true},
+		{query: "year_from=1999", wantErr: true},
+		{query: fmt.Sprintf("year_to=%d", now+6), wantErr: true},
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		from, to, err := parseYearRange(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+		return c.SendString(fmt.Sprintf("%d-%d", from, to))
+	})
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", "/?"+tt.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		body := string(raw)
+		if tt.wantErr {
+			if resp.StatusCode != fiber.StatusBadRequest {
+				t.Errorf("%q: accepted as %s", tt.query, body)
+			}
+			continue
+		}
+		if want := fmt.Sprintf("%d-%d", tt.from, tt.to); body != want {
+			t.Errorf("%q: got %q, want %q", tt.query, body, want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// dateLayout is the format of the from and to query parameters and of the bucket keys
+const dateLayout = "2006-01-02"
+
+// bucketStart returns the first day of the week (Monday, as date_trunc does) or month of t
+func bucketStart(bucket string, t time.Time) time.Time {
+	if bucket == BucketWeek {
+		return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+	}
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func nextBucket(bucket string, t time.Time) time.Time {
+	if bucket == BucketWeek {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// parseTimeSeriesRange reads bucket, from and to. Without a range the last 12 buckets
+// up to today are used.
+func parseTimeSeriesRange(ctx *fiber.Ctx) (string, time.Time, time.Time, error) {
+	bucket := ctx.Query("bucket", BucketMonth)
+	if bucket != BucketWeek && bucket != BucketMonth {
+		return "", time.Time{}, time.Time{}, errors.New("bucket must be week or month")
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := ctx.Query("to"); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, errors.New("to must be a date formatted as YYYY-MM-DD")
+		}
+		to = t
+	}
+	from := bucketStart(bucket, to)
+	if bucket == BucketWeek {
+		from = from.AddDate(0, 0, -7*11)
+	} else {
+		from = from.AddDate(0, -11, 0)
+	}
+	if v := ctx.Query("from"); v != "" {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, errors.New("from must be a date formatted as YYYY-MM-DD")
+		}
+		from = t
+	}
+
+	if from.After(to) {
+		return "", time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	if to.After(from.AddDate(maxReportYears, 0, 0)) {
+		return "", time.Time{}, time.Time{}, fmt.Errorf("a time series covers at most %d years", maxReportYears)
+	}
+	return bucket, from, to, nil
+}
+
+// percentChange is the change from previous to current in percent, nil without a previous value
+func percentChange(previous, current uint64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round((float64(current)-float64(previous))/float64(previous)*1000) / 10
+	return &change
+}
+
+// GetTimeSeries counts the surveys created, submitted, verified and rejected per week or
+// month between from and to, with empty buckets filled with zeros. Submissions include
+// resubmissions and corrections; verifications and rejections come from the status history,
+// so a survey rejected twice counts twice. It also compares the units and budget of each
+// program with the year before, for the survey years of the range; that comparison reads
+// the precomputed statistics when it can. The survey list filters and the caller's role
+// scope apply.
+func (s *reportService) GetTimeSeries(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "REPORT_TIMESERIES"
+	bucket, from, to, err := parseTimeSeriesRange(ctx)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	// Bound the timestamps by whole days in the database time zone
+	fromDate, toDate := from.Format(dateLayout), to.AddDate(0, 0, 1).Format(dateLayout)
+	bucketSQL := func(column string) string {
+		return fmt.Sprintf("to_char(date_trunc('%s', %s), 'YYYY-MM-DD')", bucket, column)
+	}
+
+	src, err := newAggregateSource(ctx, s.Db, s.Config)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
+
+	created := s.Db.Model(&models.Survey{})
+	created, err = applySurveyFilters(ctx, scope.Surveys(created))
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	var createdRows []models.TimeSeriesRow
+	if err := created.Select(bucketSQL("surveys.created_at")+" AS bucket, COUNT(*) AS created").
+		Where("surveys.created_at >= CAST(? AS date) AND surveys.created_at < CAST(? AS date)", fromDate, toDate).
+		Group("bucket").
+		Scan(&createdRows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count created surveys")
+	}
+
+	events, err := applySurveyFilters(ctx, scope.Surveys(s.Db.Model(&models.Survey{})))
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	var eventRows []models.TimeSeriesRow
+	if err := events.Select(bucketSQL("h.created_at")+" AS bucket, "+
+		"COUNT(*) FILTER (WHERE h.action IN @submits) AS submitted, "+
+		"COUNT(*) FILTER (WHERE h.to_status = @verified) AS verified, "+
+		"COUNT(*) FILTER (WHERE h.to_status IN @rejected) AS rejected", map[string]interface{}{
+		"submits":  []string{shared.ActionSubmit, shared.ActionResubmit, shared.ActionCorrect},
+		"verified": shared.StatusVerified,
+		"rejected": []string{shared.StatusRejectedBalai, shared.StatusRejectedEselon1},
+	}).
+		Joins("JOIN survey_status_history h ON h.survey_id = surveys.id").
+		Where("h.created_at >= CAST(? AS date) AND h.created_at < CAST(? AS date)", fromDate, toDate).
+		Group("bucket").
+		Scan(&eventRows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count survey verifications")
+	}
+
+	series := []models.TimeSeriesPoint{}
+	index := make(map[string]int)
+	for t := bucketStart(bucket, from); !t.After(to); t = nextBucket(bucket, t) {
+		key := t.Format(dateLayout)
+		index[key] = len(series)
+		series = append(series, models.TimeSeriesPoint{Bucket: key})
+	}
+	for _, row := range createdRows {
+		if i, ok := index[row.Bucket]; ok {
+			series[i].Created = row.Created
+		}
+	}
+	for _, row := range eventRows {
+		if i, ok := index[row.Bucket]; ok {
+			series[i].Submitted = row.Submitted
+			series[i].Verified = row.Verified
+			series[i].Rejected = row.Rejected
+		}
+	}
+
+	// Year over year per program, with the year before the range as the first baseline
+	fromYear, toYear := from.Year(), to.Year()
+	var yearRows []models.ReportGroupRow
+	if err := src.query().Select("surveys.year AS year, COALESCE(programs.id, 0) AS id, COALESCE(programs.name, '') AS name, "+
+		src.countSQL()+" AS survey_count, COALESCE(SUM(surveys.unit_target), 0) AS units, COALESCE(SUM(surveys.budget), 0) AS budget").
+		Joins("LEFT JOIN programs ON programs.id = surveys.program_id").
+		Where(src.statusSQL()+" <> ?", shared.StatusDraft).
+		Where("surveys.year BETWEEN ? AND ?", fromYear-1, toYear).
+		Group("surveys.year, programs.id, programs.name").
+		Order("name, year").
+		Scan(&yearRows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to compare program years")
+	}
+
+	type programYear struct {
+		program uint
+		year    int
+	}
+	rowOf := make(map[programYear]models.ReportGroupRow, len(yearRows))
+	programs := []models.ProgramYearOverYear{}
+	seen := make(map[uint]bool)
+	for _, row := range yearRows {
+		rowOf[programYear{row.ID, row.Year}] = row
+		if row.Year >= fromYear && !seen[row.ID] {
+			seen[row.ID] = true
+			programs = append(programs, models.ProgramYearOverYear{ProgramID: row.ID, ProgramName: row.Name})
+		}
+	}
+	for i := range programs {
+		p := &programs[i]
+		for year := fromYear; year <= toYear; year++ {
+			current, previous := rowOf[programYear{p.ProgramID, year}], rowOf[programYear{p.ProgramID, year - 1}]
+			p.Years = append(p.Years, models.ProgramYearStat{
+				Year:         year,
+				Surveys:      current.Surveys,
+				Units:        current.Units,
+				Budget:       current.Budget,
+				UnitsChange:  percentChange(previous.Units, current.Units),
+				BudgetChange: percentChange(previous.Budget, current.Budget),
+			})
+		}
+	}
+
+	return models.OkResponse(fiber.StatusOK, "Time series retrieved successfully", models.TimeSeriesAnalytics{
+		Bucket:       bucket,
+		From:         from.Format(dateLayout),
+		To:           to.Format(dateLayout),
+		Series:       series,
+		YearOverYear: programs,
+	}).WithRefreshedAt(src.refreshedAt)
+}
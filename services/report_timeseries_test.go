@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentChange(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current uint64
+		want              *float64
+	}{
+		{name: "no previous value", previous: 0, current: 10, want: nil},
+		{name: "unchanged", previous: 10, current: 10, want: floatPtr(0.0)},
+		{name: "doubled", previous: 10, current: 20, want: floatPtr(100.0)},
+		{name: "dropped to zero", previous: 10, current: 0, want: floatPtr(-100.0)},
+		{name: "rounded to one decimal", previous: 3, current: 4, want: floatPtr(33.3)},
+		{name: "rounded decrease", previous: 3, current: 2, want: floatPtr(-33.3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := percentChange(tt.previous, tt.current)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("percentChange(%d, %d) = %v, want %v", tt.previous, tt.current, floatValue(got), floatValue(tt.want))
+			}
+		})
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(dateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		bucket string
+		date   string
+		start  string
+		next   string
+	}{
+		{bucket: BucketWeek, date: "2024-05-15", start: "2024-05-13", next: "2024-05-20"}, // Wednesday
+		{bucket: BucketWeek, date: "2024-05-13", start: "2024-05-13", next: "2024-05-20"}, // Monday
+		{bucket: BucketWeek, date: "2024-05-19", start: "2024-05-13", next: "2024-05-20"}, // Sunday
+		{bucket: BucketWeek, date: "2025-01-01", start: "2024-12-30", next: "2025-01-06"}, // across years
+		{bucket: BucketMonth, date: "2024-05-15", start: "2024-05-01", next: "2024-06-01"},
+		{bucket: BucketMonth, date: "2024-12-31", start: "2024-12-01", next: "2025-01-01"},
+		{bucket: BucketMonth, date: "2024-02-29", start: "2024-02-01", next: "2024-03-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bucket+" "+tt.date, func(t *testing.T) {
+			start := bucketStart(tt.bucket, day(tt.date))
+			if got := start.Format(dateLayout); got != tt.start {
+				t.Errorf("bucketStart = %s, want %s", got, tt.start)
+			}
+			if got := nextBucket(tt.bucket, start).Format(dateLayout); got != tt.next {
+				t.Errorf("nextBucket = %s, want %s", got, tt.next)
+			}
+		})
+	}
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func floatValue(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
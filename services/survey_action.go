@@ -0,0 +1,245 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errActionRolledBack undoes an atomic bulk action once one of its surveys failed
+var errActionRolledBack = errors.New("bulk action rolled back")
+
+// actionFailure is why a bulk action could not be applied to a survey
+type actionFailure struct {
+	reason string // one of the models.ActionReason codes
+	status string // survey status that refused the action, if known
+	msg    string
+}
+
+func (f *actionFailure) Error() string {
+	return f.msg
+}
+
+// actionedStatuses are the statuses of surveys a verification level already approved or rejected
+var actionedStatuses = map[string][]string{
+	shared.LevelBalai:   {shared.StatusRejectedBalai, shared.StatusWaitingEselon1, shared.StatusRejectedEselon1, shared.StatusVerified},
+	shared.LevelEselon1: {shared.StatusRejectedEselon1, shared.StatusVerified},
+}
+
+// checkActionable tells why a verifier of the level cannot act on the survey, nil when they can
+func checkActionable(survey *models.Survey, level string, actorID uint, now time.Time) *actionFailure {
+	status := survey.GetStatusSurvey()
+	switch {
+	case status == shared.StatusDraft:
+		return &actionFailure{models.ActionReasonNotSubmitted, status, "survey has not been submitted"}
+	case status == queueStatus(level):
+		if survey.IsClaimedByOther(actorID, now) {
+			return &actionFailure{models.ActionReasonClaimedByOther, status,
+				fmt.Sprintf("survey is claimed by another verifier until %s", survey.ClaimExpiresAt.Format(time.RFC3339))}
+		}
+		return nil
+	}
+	for _, actioned := range actionedStatuses[level] {
+		if status == actioned {
+			return &actionFailure{models.ActionReasonAlreadyActioned, status, fmt.Sprintf("survey was already actioned at %s level", level)}
+		}
+	}
+	return &actionFailure{models.ActionReasonWrongLevel, status, fmt.Sprintf("survey is not waiting for %s verification", level)}
+}
+
+// fillChecklist answers the checklist template of the survey's program type for the level,
+// nil when there is none
+func fillChecklist(tx *gorm.DB, survey *models.Survey, level, action string, answers []models.ChecklistAnswerInput,
+	actor workflowActor) (*models.SurveyChecklist, error) {
+	template, err := findChecklistTemplate(tx, survey.ProgramTypeID, level)
+	if err != nil || template == nil {
+		return nil, err
+	}
+	items, err := models.FillChecklist(template, answers)
+	if err != nil {
+		return nil, &actionFailure{models.ActionReasonChecklist, survey.GetStatusSurvey(), err.Error()}
+	}
+	return &models.SurveyChecklist{
+		SurveyID:   survey.ID,
+		TemplateID: template.ID,
+		Name:       template.Name,
+		Level:      level,
+		Action:     action,
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+		Items:      items,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// ActionSurvey approves or rejects surveys in bulk and reports the outcome of each one.
+// By default every survey is actioned on its own, so one failure does not block the rest;
+// with atomic the batch is applied only if every survey can be actioned. The checklists
+// answer the template of each survey's program type; its required items block approval.
+func (s *surveyService) ActionSurvey(ctx *fiber.Ctx, input models.SurveyActionInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	role, err := utils.GetRoleNameFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot determine role")
+	}
+
+	level := s.Config.Roles.WorkflowLevel(role)
+	if level != shared.LevelBalai && level != shared.LevelEselon1 {
+		return models.ForbiddenResponse("You are not allowed to perform this action")
+	}
+
+	actor := getWorkflowActor(ctx)
+	scope := s.dataScope(ctx)
+	results := make([]models.SurveyActionResult, len(input.SurveyIDs))
+	var successCount int64
+	run := func(db *gorm.DB) {
+		for i, id := range input.SurveyIDs {
+			results[i] = s.actionOne(ctx, db, scope, id, input, level, actor)
+			if results[i].Success {
+				successCount++
+			}
+		}
+	}
+
+	if !input.Atomic {
+		run(s.Db)
+	} else {
+		// Each survey runs in a savepoint of the batch transaction, so a failed one
+		// leaves nothing behind while the others are still checked and reported
+		err := s.Db.Transaction(func(tx *gorm.DB) error {
+			run(tx)
+			if successCount < int64(len(input.SurveyIDs)) {
+				return errActionRolledBack
+			}
+			return nil
+		})
+		if errors.Is(err, errActionRolledBack) {
+			rollBackResults(results)
+			failedCount := int64(len(input.SurveyIDs)) - successCount
+			return models.NewServiceResponse(true, http.StatusUnprocessableEntity, fmt.Sprintf(
+				"%s none of %d survey(s), %d failed", input.Action, len(input.SurveyIDs), failedCount,
+			), fiber.Map{
+				"success_count": 0,
+				"failed_count":  len(input.SurveyIDs),
+				"atomic":        true,
+				"results":       results,
+			})
+		}
+		if err != nil {
+			utils.LogAudit(ctx, "ACTION_SURVEY", err.Error())
+			return models.InternalServerErrorResponse("Failed to action surveys")
+		}
+	}
+
+	failedCount := int64(len(input.SurveyIDs)) - successCount
+	return models.OkResponse(fiber.StatusOK, fmt.Sprintf(
+		"%s %d survey(s), %d failed", input.Action, successCount, failedCount,
+	), fiber.Map{
+		"success_count": successCount,
+		"failed_count":  failedCount,
+		"atomic":        input.Atomic,
+		"results":       results,
+	})
+}
+
+// actionOne applies the bulk action to one survey in a transaction of its own, a savepoint
+// when db already is one, and reports the outcome
+func (s *surveyService) actionOne(ctx *fiber.Ctx, db *gorm.DB, scope *DataScope, id string,
+	input models.SurveyActionInput, level string, actor workflowActor) models.SurveyActionResult {
+	result := models.SurveyActionResult{SurveyID: id}
+	var survey models.Survey
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			return &actionFailure{reason: models.ActionReasonNotFound, msg: "survey not found"}
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).First(&survey).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &actionFailure{reason: models.ActionReasonNotFound, msg: "survey not found"}
+			}
+			return err
+		}
+		visible, err := scope.CanSee(tx, survey.ID)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return &actionFailure{models.ActionReasonOutOfScope, survey.GetStatusSurvey(), "survey is outside your Balai"}
+		}
+		if failure := checkActionable(&survey, level, actor.ID, time.Now()); failure != nil {
+			return failure
+		}
+		checklist, err := fillChecklist(tx, &survey, level, input.Action, input.Checklists[id], actor)
+		if err != nil {
+			return err
+		}
+		if checklist != nil && input.Action == shared.Approved {
+			if missing := checklist.MissingRequired(); len(missing) > 0 {
+				return &actionFailure{models.ActionReasonChecklist, survey.GetStatusSurvey(),
+					"required checklist items are not checked: " + strings.Join(missing, ", ")}
+			}
+		}
+		if _, err := s.Workflow.Transition(tx, &survey, input.Action, actor, input.Notes); err != nil {
+			return err
+		}
+		if checklist == nil {
+			return nil
+		}
+		checklist.Round = survey.RevisionRound
+		return tx.Create(checklist).Error
+	})
+	if err == nil {
+		result.Success, result.Status = true, survey.GetStatusSurvey()
+		return result
+	}
+
+	utils.LogAudit(ctx, "ACTION_SURVEY", fmt.Sprintf("survey %s: %v", id, err))
+	return failedActionResult(result, err)
+}
+
+// failedActionResult reports why the action failed on a survey with a reason code.
+// Unexpected errors are not shown to the caller.
+func failedActionResult(result models.SurveyActionResult, err error) models.SurveyActionResult {
+	var failure *actionFailure
+	switch {
+	case errors.As(err, &failure):
+		result.Reason, result.Status = failure.reason, failure.status
+	case errors.Is(err, ErrSurveyClaimed):
+		result.Reason = models.ActionReasonClaimedByOther
+	case errors.Is(err, ErrTransitionForbidden):
+		result.Reason = models.ActionReasonWrongLevel
+	case errors.Is(err, ErrIllegalTransition):
+		result.Reason = models.ActionReasonAlreadyActioned
+	default:
+		result.Reason = models.ActionReasonError
+		result.Message = "failed to apply the action"
+		return result
+	}
+	result.Message = err.Error()
+	return result
+}
+
+// rollBackResults reports the surveys of a failed atomic batch that were actioned as rolled back
+func rollBackResults(results []models.SurveyActionResult) {
+	for i := range results {
+		if results[i].Success {
+			results[i].Success, results[i].Status = false, ""
+			results[i].Reason = models.ActionReasonRolledBack
+			results[i].Message = "rolled back because another survey of the batch failed"
+		}
+	}
+}
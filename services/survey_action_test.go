@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+)
+
+func TestCheckActionable(t *testing.T) {
+	now := time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		status string
+		level  string
+		reason string // empty when the level may act
+	}{
+		{shared.StatusDraft, shared.LevelBalai, models.ActionReasonNotSubmitted},
+		{shared.StatusWaitingBalai, shared.LevelBalai, ""},
+		{shared.StatusWaitingBalai, shared.LevelEselon1, models.ActionReasonWrongLevel},
+		{shared.StatusWaitingEselon1, shared.LevelEselon1, ""},
+		{shared.StatusWaitingEselon1, shared.LevelBalai, models.ActionReasonAlreadyActioned},
+		{shared.StatusRejectedBalai, shared.LevelBalai, models.ActionReasonAlreadyActioned},
+		{shared.StatusRejectedBalai, shared.LevelEselon1, models.ActionReasonWrongLevel},
+		{shared.StatusRejectedEselon1, shared.LevelEselon1, models.ActionReasonAlreadyActioned},
+		{shared.StatusVerified, shared.LevelBalai, models.ActionReasonAlreadyActioned},
+		{shared.StatusVerified, shared.LevelEselon1, models.ActionReasonAlreadyActioned},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%s", tt.level, tt.status), func(t *testing.T) {
+			var survey models.Survey
+			survey.ApplyStatus(tt.status)
+			failure := checkActionable(&survey, tt.level, 8, now)
+			switch {
+			case tt.reason == "" && failure != nil:
+				t.Errorf("refused with %s: %s", failure.reason, failure.msg)
+			case tt.reason != "" && failure == nil:
+				t.Errorf("allowed, want %s", tt.reason)
+			case failure != nil && (failure.reason != tt.reason || failure.status != tt.status):
+				t.Errorf("reason %s status %q, want %s %q", failure.reason, failure.status, tt.reason, tt.status)
+			}
+		})
+	}
+
+	t.Run("claimed", func(t *testing.T) {
+		var survey models.Survey
+		survey.ApplyStatus(shared.StatusWaitingBalai)
+		survey.Claim(9, now, time.Hour)
+		if f := checkActionable(&survey, shared.LevelBalai, 8, now); f == nil || f.reason != models.ActionReasonClaimedByOther {
+			t.Errorf("other verifier's claim: %v", f)
+		}
+		if f := checkActionable(&survey, shared.LevelBalai, 9, now); f != nil {
+			t.Errorf("own claim refused: %s", f.reason)
+		}
+	})
+}
+
+func TestFailedActionResult(t *testing.T) {
+	tests := []struct {
+		err     error
+		reason  string
+		message bool
+	}{
+		{&actionFailure{models.ActionReasonOutOfScope, shared.StatusWaitingBalai, "survey is outside your Balai"}, models.ActionReasonOutOfScope, true},
+		{fmt.Errorf("%w until tomorrow", ErrSurveyClaimed), models.ActionReasonClaimedByOther, true},
+		{fmt.Errorf("%w: Approved requires Balai level", ErrTransitionForbidden), models.ActionReasonWrongLevel, true},
+		{fmt.Errorf("%w: cannot Approved", ErrIllegalTransition), models.ActionReasonAlreadyActioned, true},
+		{errors.New("pq: deadlock detected"), models.ActionReasonError, false},
+	}
+	for _, tt := range tests {
+		r := failedActionResult(models.SurveyActionResult{SurveyID: "12"}, tt.err)
+		if r.Success || r.Reason != tt.reason || r.SurveyID != "12" {
+			t.Errorf("%v: %+v, want reason %s", tt.err, r, tt.reason)
+		}
+		// Database errors must not leak to the caller
+		if (r.Message == tt.err.Error()) != tt.message {
+			t.Errorf("%v: message %q", tt.err, r.Message)
+		}
+	}
+}
+
+func TestRollBackResults(t *testing.T) {
+	results := []models.SurveyActionResult{
+		{SurveyID: "1", Success: true, Status: shared.StatusWaitingEselon1},
+		{SurveyID: "2", Reason: models.ActionReasonNotSubmitted, Status: shared.StatusDraft, Message: "survey has not been submitted"},
+		{SurveyID: "3", Success: true, Status: shared.StatusWaitingEselon1},
+	}
+	rollBackResults(results)
+
+	for _, i := range []int{0, 2} {
+		if r := results[i]; r.Success || r.Status != "" || r.Reason != models.ActionReasonRolledBack {
+			t.Errorf("actioned survey %s: %+v", r.SurveyID, r)
+		}
+	}
+	// The failure that caused the rollback keeps its own reason
+	if r := results[1]; r.Reason != models.ActionReasonNotSubmitted || r.Status != shared.StatusDraft {
+		t.Errorf("failed survey: %+v", r)
+	}
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/internal/context"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrAssignment is wrapped by the errors refusing to fulfil an assignment with a survey
+var ErrAssignment = errors.New("assignment cannot be fulfilled")
+
+type SurveyAssignmentService interface {
+	GetAll(ctx *fiber.Ctx) models.ServiceResponse
+	GetMine(ctx *fiber.Ctx) models.ServiceResponse
+	GetByID(ctx *fiber.Ctx, id string) models.ServiceResponse
+	Create(ctx *fiber.Ctx, input *models.SurveyAssignmentInput) models.ServiceResponse
+	Update(ctx *fiber.Ctx, input *models.SurveyAssignmentInput) models.ServiceResponse
+	Delete(ctx *fiber.Ctx, id string) models.ServiceResponse
+	Accept(ctx *fiber.Ctx, id string) models.ServiceResponse
+}
+
+type surveyAssignmentService struct {
+	Db     *gorm.DB
+	Config *config.Config
+}
+
+func NewSurveyAssignmentService(ctx *context.AppContext) SurveyAssignmentService {
+	return &surveyAssignmentService{
+		Db:     ctx.DB,
+		Config: ctx.Config,
+	}
+}
+
+// overdueAssignmentSQL matches open assignments past their due date
+var overdueAssignmentSQL = fmt.Sprintf("survey_assignments.status IN ('%s', '%s') AND survey_assignments.due_date < CURRENT_DATE",
+	shared.AssignmentAssigned, shared.AssignmentAccepted)
+
+// preloadAssignment loads what an assignment response shows
+func preloadAssignment(db *gorm.DB) *gorm.DB {
+	return db.Preload("Balai").Preload("Program").Preload("Province").Preload("District").
+		Preload("Subdistrict").Preload("Village").Preload("Assignee")
+}
+
+// dataScope resolves the scope of the caller, see DataScope.Assignments
+func (s *surveyAssignmentService) dataScope(ctx *fiber.Ctx) *DataScope {
+	return newDataScope(ctx, s.Db, s.Config.Roles)
+}
+
+func (s *surveyAssignmentService) list(ctx *fiber.Ctx, db *gorm.DB) models.ServiceResponse {
+	if status := ctx.Query("status"); status != "" {
+		db = db.Where("survey_assignments.status IN ?", utils.SplitAndTrim(status, ","))
+	}
+	if assigneeID := ctx.Query("assignee_id"); assigneeID != "" {
+		db = db.Where("survey_assignments.assignee_id = ?", assigneeID)
+	}
+	if ctx.QueryBool("overdue", false) {
+		db = db.Where(overdueAssignmentSQL)
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to count assignments")
+	}
+
+	var data []models.SurveyAssignment
+	if err := preloadAssignment(db).Limit(limit).Offset(offset).
+		Order("survey_assignments.due_date ASC, survey_assignments.id ASC").Find(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve assignments")
+	}
+
+	return models.OkResponse(http.StatusOK, "Success", fiber.Map{
+		"data":       models.ToSurveyAssignmentResponses(data),
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetAll lists the assignments visible to the caller, filtered by status, assignee_id
+// and overdue=true (open assignments past their due date)
+func (s *surveyAssignmentService) GetAll(ctx *fiber.Ctx) models.ServiceResponse {
+	return s.list(ctx, s.dataScope(ctx).Assignments(s.Db.Model(&models.SurveyAssignment{})))
+}
+
+// GetMine lists the assignments given to the calling surveyor
+func (s *surveyAssignmentService) GetMine(ctx *fiber.Ctx) models.ServiceResponse {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
+	}
+	return s.list(ctx, s.Db.Model(&models.SurveyAssignment{}).Where("survey_assignments.assignee_id = ?", userID))
+}
+
+// find loads an assignment visible to the caller
+func (s *surveyAssignmentService) find(ctx *fiber.Ctx, id interface{}) (models.SurveyAssignment, models.ServiceResponse, bool) {
+	var data models.SurveyAssignment
+	db := s.dataScope(ctx).Assignments(s.Db.Model(&models.SurveyAssignment{}))
+	if err := preloadAssignment(db).Where("survey_assignments.id = ?", id).First(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return data, models.NotFoundResponse(fmt.Sprintf("Assignment with id %v not found", id)), false
+		}
+		return data, models.InternalServerErrorResponse("Error retrieving assignment"), false
+	}
+	return data, models.ServiceResponse{}, true
+}
+
+func (s *surveyAssignmentService) GetByID(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	data, res, ok := s.find(ctx, id)
+	if !ok {
+		return res
+	}
+	return models.OkResponse(http.StatusOK, "Success", data.ToResponse())
+}
+
+// checkAssignmentInput makes sure the assignee is a surveyor of the Balai and that the
+// program and location exist, each region within its parent
+func (s *surveyAssignmentService) checkAssignmentInput(balaiID uint, input *models.SurveyAssignmentInput) (models.ServiceResponse, bool) {
+	var count int64
+	if err := s.Db.Model(&models.User{}).
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Joins("JOIN profiles ON profiles.user_id = users.id AND profiles.deleted_at IS NULL").
+		Where("users.id = ? AND roles.name = ? AND profiles.balai_id = ?", input.AssigneeID, s.Config.Roles.Surveyor, balaiID).
+		Count(&count).Error; err != nil {
+		return models.InternalServerErrorResponse("Error checking surveyor"), false
+	}
+	if count == 0 {
+		return models.BadRequestResponse("Assignee must be a surveyor of your Balai"), false
+	}
+
+	exists := func(model interface{}, query string, args ...interface{}) bool {
+		var count int64
+		s.Db.Model(model).Where(query, args...).Count(&count)
+		return count > 0
+	}
+	if !exists(&models.Program{}, "id = ?", input.ProgramID) {
+		return models.BadRequestResponse("Program not found"), false
+	}
+	if !exists(&models.District{}, "id = ? AND province_id = ?", input.DistrictID, input.ProvinceID) {
+		return models.BadRequestResponse("District not found in the province"), false
+	}
+	if input.SubdistrictID != 0 && !exists(&models.Subdistrict{}, "id = ? AND district_id = ?", input.SubdistrictID, input.DistrictID) {
+		return models.BadRequestResponse("Subdistrict not found in the district"), false
+	}
+	if input.VillageID != 0 && !exists(&models.Village{}, "id = ? AND subdistrict_id = ?", input.VillageID, input.SubdistrictID) {
+		return models.BadRequestResponse("Village not found in the subdistrict"), false
+	}
+	return models.ServiceResponse{}, true
+}
+
+// Create lets an Admin Balai give a surveyor of their Balai a survey to make
+func (s *surveyAssignmentService) Create(ctx *fiber.Ctx, input *models.SurveyAssignmentInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
+	}
+	balaiID := s.dataScope(ctx).BalaiID
+	if balaiID == 0 {
+		return models.ForbiddenResponse("Your profile is not assigned to a Balai")
+	}
+	if res, ok := s.checkAssignmentInput(balaiID, input); !ok {
+		return res
+	}
+
+	data := input.ToModel(balaiID, uint(userID))
+	if err := s.Db.Create(&data).Error; err != nil {
+		utils.LogAudit(ctx, "CREATE_ASSIGNMENT", err.Error())
+		return models.InternalServerErrorResponse("Failed to create assignment")
+	}
+	preloadAssignment(s.Db).First(&data, data.ID)
+	utils.LogAudit(ctx, "CREATE_ASSIGNMENT", fmt.Sprintf("Assignment %d given to user %d", data.ID, data.AssigneeID))
+	return models.OkResponse(http.StatusCreated, "Assignment created", data.ToResponse())
+}
+
+// Update changes an open assignment of the caller's Balai; a new assignee has to accept it again
+func (s *surveyAssignmentService) Update(ctx *fiber.Ctx, input *models.SurveyAssignmentInput) models.ServiceResponse {
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	data, res, ok := s.find(ctx, input.ID)
+	if !ok {
+		return res
+	}
+	if !data.IsOpen() {
+		return models.BadRequestResponse(fmt.Sprintf("Assignment is %s and can no longer be changed", data.Status))
+	}
+	if res, ok := s.checkAssignmentInput(data.BalaiID, input); !ok {
+		return res
+	}
+
+	data.UpdateFromInput(input)
+	if err := s.Db.Omit(clause.Associations).Save(&data).Error; err != nil {
+		utils.LogAudit(ctx, "UPDATE_ASSIGNMENT", err.Error())
+		return models.InternalServerErrorResponse("Failed to update assignment")
+	}
+	data, _, _ = s.find(ctx, data.ID)
+	utils.LogAudit(ctx, "UPDATE_ASSIGNMENT", fmt.Sprintf("Assignment %d updated", data.ID))
+	return models.OkResponse(http.StatusOK, "Assignment updated", data.ToResponse())
+}
+
+// Delete withdraws an open assignment of the caller's Balai
+func (s *surveyAssignmentService) Delete(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	data, res, ok := s.find(ctx, id)
+	if !ok {
+		return res
+	}
+	if !data.IsOpen() {
+		return models.BadRequestResponse("A fulfilled assignment cannot be deleted")
+	}
+
+	data.MarkDeleted(utils.GetActor(ctx))
+	if err := s.Db.Omit(clause.Associations).Save(&data).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to delete assignment")
+	}
+	utils.LogAudit(ctx, "DELETE_ASSIGNMENT", fmt.Sprintf("Assignment %d deleted", data.ID))
+	return models.OkResponse(http.StatusOK, "Assignment deleted", nil)
+}
+
+// Accept lets the assigned surveyor take the assignment on
+func (s *surveyAssignmentService) Accept(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	data, res, ok := s.find(ctx, id)
+	if !ok {
+		return res
+	}
+	if data.Status != shared.AssignmentAssigned {
+		return models.BadRequestResponse(fmt.Sprintf("Assignment is already %s", data.Status))
+	}
+
+	now := time.Now()
+	result := s.Db.Model(&models.SurveyAssignment{}).
+		Where("id = ? AND status = ?", data.ID, shared.AssignmentAssigned).
+		Updates(map[string]interface{}{
+			"status":      shared.AssignmentAccepted,
+			"accepted_at": now,
+			"updated_by":  utils.GetActor(ctx),
+		})
+	if result.Error != nil {
+		return models.InternalServerErrorResponse("Failed to accept assignment")
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrResponse(http.StatusConflict, "Assignment changed meanwhile, reload it")
+	}
+	data.Status, data.AcceptedAt = shared.AssignmentAccepted, &now
+
+	utils.LogAudit(ctx, "ACCEPT_ASSIGNMENT", fmt.Sprintf("Assignment %d accepted", data.ID))
+	return models.OkResponse(http.StatusOK, "Assignment accepted", data.ToResponse())
+}
+
+// fulfilAssignment links a survey being created to the accepted assignment it fulfils.
+// It runs in the survey's transaction so both are saved or neither.
+func fulfilAssignment(tx *gorm.DB, assignmentID uint, survey *models.Survey) error {
+	var a models.SurveyAssignment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", assignmentID).First(&a).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: assignment %d not found", ErrAssignment, assignmentID)
+		}
+		return err
+	}
+	if a.AssigneeID != survey.UserID {
+		return fmt.Errorf("%w: assignment %d is not assigned to you", ErrAssignment, a.ID)
+	}
+	switch a.Status {
+	case shared.AssignmentAssigned:
+		return fmt.Errorf("%w: accept assignment %d first", ErrAssignment, a.ID)
+	case shared.AssignmentFulfilled:
+		return fmt.Errorf("%w: assignment %d is already fulfilled", ErrAssignment, a.ID)
+	}
+	if err := a.CheckFulfilment(survey); err != nil {
+		return fmt.Errorf("%w: %v", ErrAssignment, err)
+	}
+
+	now := time.Now()
+	return tx.Model(&models.SurveyAssignment{}).Where("id = ?", a.ID).Updates(map[string]interface{}{
+		"status":       shared.AssignmentFulfilled,
+		"fulfilled_at": now,
+		"survey_id":    survey.ID,
+	}).Error
+}
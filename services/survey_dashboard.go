@@ -0,0 +1,245 @@
+package services
+
+import (
+	"math"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// dashboardSource is what every dashboard aggregates over: the caller's surveys narrowed
+// by the same filters as GetAllSurveys, from the precomputed statistics when possible
+func (s *surveyService) dashboardSource(ctx *fiber.Ctx) (*aggregateSource, error) {
+	return newAggregateSource(ctx, s.Db, s.Config)
+}
+
+// percentOf rounds part/total to one decimal
+func percentOf(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
+
+func (s *surveyService) GetSurveysByResource(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "DASHBOARD_RESOURCE"
+	src, err := s.dashboardSource(ctx)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	var rows []models.DashboardGroupRow
+	if err := src.query().Select("COALESCE(resources.tag, '') AS name, " + src.aggregateSQL()).
+		Joins("LEFT JOIN resources ON resources.id = surveys.resource_id").
+		Group("resources.tag").
+		Scan(&rows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("cannot count surveys by resource tag")
+	}
+
+	// Each tag is shown with the name of its first resource
+	var resources []models.Resource
+	if err := s.Db.Order("id").Find(&resources).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Error retrieving resources")
+	}
+	tagToName := make(map[string]string)
+	for _, r := range resources {
+		if _, ok := tagToName[r.Tag]; !ok {
+			tagToName[r.Tag] = r.Name
+		}
+	}
+
+	byTag := make(map[string]models.DashboardGroupRow, len(rows))
+	var total int64
+	for _, r := range rows {
+		byTag[r.Name] = r
+		total += r.Total
+	}
+	tags := []string{s.Config.Resource.TagNegara, s.Config.Resource.TagPengembang, s.Config.Resource.TagSwadaya, s.Config.Resource.TagGotongRoyong}
+	result := make([]models.DashboardResource, len(tags))
+	for i, tag := range tags {
+		r := byTag[tag]
+		result[i] = models.DashboardResource{
+			Name:    tagToName[tag],
+			Tag:     tag,
+			Total:   r.Total,
+			Percent: percentOf(r.Total, total),
+			Units:   r.Units,
+			Budget:  r.Budget,
+		}
+	}
+
+	utils.LogAudit(ctx, action, "Success")
+	return models.OkResponse(200, "Success", result).WithRefreshedAt(src.refreshedAt)
+}
+
+func (s *surveyService) GetSurveysByProgramType(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "DASHBOARD_PROGRAM_TYPE"
+	src, err := s.dashboardSource(ctx)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	var rows []models.DashboardGroupRow
+	if err := src.query().Select("surveys.program_type_id AS id, " + src.aggregateSQL()).
+		Group("surveys.program_type_id").
+		Scan(&rows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("cannot count surveys by program type")
+	}
+
+	var programTypes []models.ProgramType
+	if err := s.Db.Order("id").Find(&programTypes).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Error retrieving program types")
+	}
+
+	byID := make(map[uint]models.DashboardGroupRow, len(rows))
+	var total int64
+	for _, r := range rows {
+		byID[r.ID] = r
+		total += r.Total
+	}
+	result := make([]models.DashboardProgramType, len(programTypes))
+	for i, pt := range programTypes {
+		r := byID[pt.ID]
+		result[i] = models.DashboardProgramType{
+			ID:      pt.ID,
+			Name:    pt.Name,
+			Total:   int(r.Total),
+			Percent: percentOf(r.Total, total),
+			Units:   r.Units,
+			Budget:  r.Budget,
+		}
+	}
+
+	utils.LogAudit(ctx, action, "Success")
+	return models.OkResponse(200, "Success", result).WithRefreshedAt(src.refreshedAt)
+}
+
+func (s *surveyService) GetSurveysByVerificationStatus(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "DASHBOARD_VERIFIED"
+	src, err := s.dashboardSource(ctx)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	// Verified by Eselon 1, counted in the same pass as all surveys
+	verified := "FILTER (WHERE " + src.statusSQL() + " = @verified)"
+	var row struct {
+		Total          int64
+		VerifiedCount  int64
+		Units          uint64
+		VerifiedUnits  uint64
+		Budget         uint64
+		VerifiedBudget uint64
+	}
+	verifiedCount := "COUNT(*) " + verified
+	if src.statistics {
+		verifiedCount = "COALESCE(SUM(surveys.survey_count) " + verified + ", 0)"
+	}
+	if err := src.query().Select(src.aggregateSQL()+
+		", "+verifiedCount+" AS verified_count"+
+		", COALESCE(SUM(surveys.unit_target) "+verified+", 0) AS verified_units"+
+		", COALESCE(SUM(surveys.budget) "+verified+", 0) AS verified_budget",
+		map[string]interface{}{"verified": shared.StatusVerified}).
+		Scan(&row).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count verified surveys")
+	}
+
+	result := models.DashboardVerified{
+		Name:           "Survey Verified Recap",
+		Total:          int(row.Total),
+		VerifiedCount:  int(row.VerifiedCount),
+		Percent:        percentOf(row.VerifiedCount, row.Total),
+		Units:          row.Units,
+		VerifiedUnits:  row.VerifiedUnits,
+		Budget:         row.Budget,
+		VerifiedBudget: row.VerifiedBudget,
+	}
+
+	utils.LogAudit(ctx, action, "Success")
+	return models.OkResponse(200, "Success", result).WithRefreshedAt(src.refreshedAt)
+}
+
+// newStatusBreakdown lists every derived status with its count, zero when absent
+func newStatusBreakdown(counts map[string]int64) models.StatusBreakdown {
+	b := models.StatusBreakdown{Counts: make([]models.StatusCount, len(models.SurveyStatuses))}
+	for i, status := range models.SurveyStatuses {
+		b.Counts[i] = models.StatusCount{Status: status, Count: counts[status]}
+		b.Total += counts[status]
+	}
+	return b
+}
+
+// GetSurveysByStatus counts the caller's surveys in every workflow status, overall, per
+// Balai of the surveyor and per month of first submission. It accepts the list filters.
+func (s *surveyService) GetSurveysByStatus(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "DASHBOARD_STATUS"
+	src, err := s.dashboardSource(ctx)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	status := src.statusSQL() + " AS status, " + src.countSQL() + " AS count"
+	// Aliased so the query stays free to join profiles under its own name
+	balaiJoins := "LEFT JOIN profiles owner_profiles ON owner_profiles.user_id = surveys.user_id AND owner_profiles.deleted_at IS NULL " +
+		"LEFT JOIN balais ON balais.id = owner_profiles.balai_id"
+
+	var byBalai, byMonth []models.StatusGroupRow
+	if err := src.query().Select("COALESCE(balais.id, 0) AS balai_id, COALESCE(balais.name, '') AS balai_name, " + status).
+		Joins(balaiJoins).
+		Group("1, 2, 3").
+		Order("balai_name, balai_id").
+		Scan(&byBalai).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count surveys by balai and status")
+	}
+
+	submittedYear, submittedMonth := src.submittedSQL()
+	if err := src.query().Select(submittedYear + " AS year, " + submittedMonth + " AS month, " + status).
+		Where(submittedYear + " > 0").
+		Group("1, 2, 3").
+		Order("year, month").
+		Scan(&byMonth).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count surveys by month and status")
+	}
+
+	// Rows come sorted by group, each group holds one row per status
+	totals := make(map[string]int64)
+	result := models.DashboardStatus{ByBalai: []models.BalaiStatusBreakdown{}, ByMonth: []models.MonthStatusBreakdown{}}
+	for i := 0; i < len(byBalai); {
+		row := byBalai[i]
+		counts := make(map[string]int64)
+		for ; i < len(byBalai) && byBalai[i].BalaiID == row.BalaiID; i++ {
+			counts[byBalai[i].Status] += byBalai[i].Count
+			totals[byBalai[i].Status] += byBalai[i].Count
+		}
+		result.ByBalai = append(result.ByBalai, models.BalaiStatusBreakdown{
+			BalaiID:         row.BalaiID,
+			BalaiName:       row.BalaiName,
+			StatusBreakdown: newStatusBreakdown(counts),
+		})
+	}
+	for i := 0; i < len(byMonth); {
+		row := byMonth[i]
+		counts := make(map[string]int64)
+		for ; i < len(byMonth) && byMonth[i].Year == row.Year && byMonth[i].Month == row.Month; i++ {
+			counts[byMonth[i].Status] += byMonth[i].Count
+		}
+		result.ByMonth = append(result.ByMonth, models.MonthStatusBreakdown{
+			Year:            row.Year,
+			Month:           row.Month,
+			StatusBreakdown: newStatusBreakdown(counts),
+		})
+	}
+	result.StatusBreakdown = newStatusBreakdown(totals)
+
+	utils.LogAudit(ctx, action, "Success")
+	return models.OkResponse(200, "Success", result).WithRefreshedAt(src.refreshedAt)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+
+	"housing-survey-api/models"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// surveyFilterQuery selects the surveys visible to the caller, narrowed by the same
+// filters as GetAllSurveys
+func (s *surveyService) surveyFilterQuery(ctx *fiber.Ctx) (*gorm.DB, error) {
+	return applySurveyFilters(ctx, s.dataScope(ctx).Surveys(s.Db.Model(&models.Survey{})))
+}
+
+// surveyRowQuery selects the flat export rows of the surveys of surveyFilterQuery
+func (s *surveyService) surveyRowQuery(ctx *fiber.Ctx) (*gorm.DB, error) {
+	db, err := s.surveyFilterQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return db.Select(models.SurveyRowColumns).
+		Joins(models.SurveyRowJoins).
+		Order("surveys.id"), nil
+}
+
+// ExportSurveys returns a writer sending the surveys visible to the caller as a
+// "csv" or "xlsx" spreadsheet. It accepts the same filters as GetAllSurveys.
+// CSV is streamed row by row. XLSX is built in memory and written out at the end,
+// so it is refused above utils.MaxXLSXRows rows in favour of CSV.
+func (s *surveyService) ExportSurveys(ctx *fiber.Ctx, format string) (func(w *bufio.Writer), models.ServiceResponse) {
+	if _, ok := utils.SpreadsheetContentTypes[format]; !ok {
+		return nil, models.BadRequestResponse("format must be csv or xlsx")
+	}
+	query, err := s.surveyRowQuery(ctx)
+	if err != nil {
+		return nil, models.BadRequestResponse(err.Error())
+	}
+	if format == "xlsx" {
+		filtered, err := s.surveyFilterQuery(ctx)
+		if err != nil {
+			return nil, models.BadRequestResponse(err.Error())
+		}
+		var total int64
+		if err := filtered.Count(&total).Error; err != nil {
+			return nil, models.InternalServerErrorResponse("Failed to count surveys")
+		}
+		if total > utils.MaxXLSXRows {
+			return nil, models.BadRequestResponse(fmt.Sprintf("XLSX exports are limited to %d surveys, narrow the filters or use format=csv", utils.MaxXLSXRows))
+		}
+		// Surveys created since the count must not grow the workbook past the cap
+		query = query.Limit(utils.MaxXLSXRows)
+	}
+
+	// Fail before the stream starts when the query itself is broken
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, models.InternalServerErrorResponse("Failed to retrieve surveys")
+	}
+	utils.LogAudit(ctx, "EXPORT_SURVEY", "Exported surveys as "+format+" with "+string(ctx.Request().URI().QueryString()))
+
+	write := func(w *bufio.Writer) {
+		defer rows.Close()
+		sheet, err := utils.NewSpreadsheetWriter(format, w, models.SurveyExportHeaders)
+		if err != nil {
+			log.Printf("survey export: %v", err)
+			return
+		}
+		for rows.Next() {
+			var row models.SurveyRow
+			if err := s.Db.ScanRows(rows, &row); err != nil {
+				log.Printf("survey export: %v", err)
+				break
+			}
+			if err := sheet.WriteRow(row.ExportValues()); err != nil {
+				log.Printf("survey export: %v", err)
+				break
+			}
+		}
+		if err := rows.Err(); err != nil {
+			log.Printf("survey export: %v", err)
+		}
+		if err := sheet.Close(); err != nil {
+			log.Printf("survey export: %v", err)
+		}
+		w.Flush()
+	}
+	return write, models.OkResponse(fiber.StatusOK, "Success", nil)
+}
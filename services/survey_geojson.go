@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"housing-survey-api/models"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// geoJSONFlushEvery is the number of features written between flushes to the client
+const geoJSONFlushEvery = 500
+
+// geoJSONTruncated closes a collection whose export failed mid-stream
+const geoJSONTruncated = `],"error":"Export stopped early, the collection is incomplete"}`
+
+// ExportSurveysGeoJSON returns a writer streaming the surveys visible to the caller as a
+// GeoJSON FeatureCollection. It accepts the same filters as GetAllSurveys; surveys
+// without a parsed coordinate are left out.
+//
+// The status line is already sent when a row fails mid-stream, so the collection is then
+// closed early with a top-level "error" member next to the features written so far.
+// Clients must treat a collection carrying "error" as truncated.
+func (s *surveyService) ExportSurveysGeoJSON(ctx *fiber.Ctx) (func(w *bufio.Writer), models.ServiceResponse) {
+	query, err := s.surveyRowQuery(ctx)
+	if err != nil {
+		return nil, models.BadRequestResponse(err.Error())
+	}
+	query = query.Where("surveys.latitude IS NOT NULL AND surveys.longitude IS NOT NULL")
+
+	// Fail before the stream starts when the query itself is broken
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, models.InternalServerErrorResponse("Failed to retrieve surveys")
+	}
+	audit := utils.AuditFunc(ctx)
+
+	write := func(w *bufio.Writer) {
+		defer rows.Close()
+		count := 0
+		fail := func(err error) {
+			log.Printf("geojson export: %v", err)
+			audit("EXPORT_SURVEY_FAILED", fmt.Sprintf("GeoJSON export stopped after %d features: %v", count, err))
+			w.WriteString(geoJSONTruncated)
+			w.Flush()
+		}
+
+		w.WriteString(`{"type":"FeatureCollection","features":[`)
+		for rows.Next() {
+			var row models.SurveyRow
+			if err := s.Db.ScanRows(rows, &row); err != nil {
+				fail(err)
+				return
+			}
+			feature, err := json.Marshal(row.ToFeature())
+			if err != nil {
+				fail(err)
+				return
+			}
+			if count > 0 {
+				w.WriteByte(',')
+			}
+			w.Write(feature)
+			count++
+			if count%geoJSONFlushEvery == 0 {
+				if err := w.Flush(); err != nil {
+					// Client went away
+					return
+				}
+			}
+		}
+		if err := rows.Err(); err != nil {
+			fail(err)
+			return
+		}
+		w.WriteString(`]}`)
+		w.Flush()
+	}
+	return write, models.OkResponse(fiber.StatusOK, "Success", nil)
+}
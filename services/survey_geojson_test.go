@@ -0,0 +1,22 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGeoJSONTruncatedIsValid(t *testing.T) {
+	for _, features := range []string{``, `{"type":"Feature"}`, `{"type":"Feature"},{"type":"Feature"}`} {
+		body := `{"type":"FeatureCollection","features":[` + features + geoJSONTruncated
+		var collection struct {
+			Features []json.RawMessage `json:"features"`
+			Error    string            `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(body), &collection); err != nil {
+			t.Fatalf("%s: %v", body, err)
+		}
+		if collection.Error == "" {
+			t.Errorf("%s: missing error member", body)
+		}
+	}
+}
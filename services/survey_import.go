@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxImportRows keeps a single import within one reasonable transaction
+const maxImportRows = 2000
+
+// ImportSurveys creates the surveys of an uploaded CSV or XLSX file (multipart field "file")
+// for the calling surveyor. Every row is checked first; with dry_run=true the check result
+// is returned without saving anything, otherwise all rows are saved in one transaction
+// only when every row is valid.
+func (s *surveyService) ImportSurveys(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "IMPORT_SURVEY"
+	dryRun := ctx.QueryBool("dry_run", false)
+
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
+	}
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		return models.BadRequestResponse("A CSV or XLSX file is required in field 'file'")
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return models.BadRequestResponse("Cannot read " + fh.Filename)
+	}
+	records, err := utils.ReadSpreadsheet(fh.Filename, f)
+	f.Close()
+	if err != nil {
+		return models.BadRequestResponse(fmt.Sprintf("Cannot read %s: %v", fh.Filename, err))
+	}
+
+	rows, err := parseImportRows(records)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+
+	actor := getWorkflowActor(ctx)
+	actorName := utils.GetActor(ctx)
+	lookup := newImportLookup(s.Db)
+	for i := range rows {
+		row := &rows[i]
+		row.ToSurveyInput()
+		row.Input.UserID = uint(userID)
+		row.Input.Actor = actorName
+		row.Input.Mode = shared.Create
+		lookup.resolve(row)
+		if err := row.Input.Validate(); err != nil {
+			row.Errors = append(row.Errors, strings.Split(err.Error(), "; ")...)
+		}
+	}
+
+	result := models.ToSurveyImportResult(rows, dryRun)
+	if dryRun {
+		return models.OkResponse(fiber.StatusOK, "Survey import checked", result)
+	}
+	if result.InvalidRows > 0 {
+		return models.NewServiceResponse(true, fiber.StatusBadRequest,
+			fmt.Sprintf("%d of %d rows are invalid, nothing was imported", result.InvalidRows, result.TotalRows), result)
+	}
+
+	err = s.Db.Transaction(func(tx *gorm.DB) error {
+		for i, row := range rows {
+			survey := row.Input.ToSurvey()
+			if err := s.insertSurvey(tx, &survey, row.Input.IsSubmitted, actor); err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			result.Rows[i].SurveyID = survey.ID
+		}
+		return nil
+	})
+	if err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return workflowErrorResponse(err, "Failed to import surveys")
+	}
+	result.Imported = len(rows)
+
+	utils.LogAudit(ctx, action, fmt.Sprintf("Imported %d surveys from %s", result.Imported, fh.Filename))
+	return models.OkResponse(fiber.StatusCreated, "Surveys imported successfully", result)
+}
+
+// parseImportRows maps the spreadsheet rows to their header, skipping empty rows
+func parseImportRows(records [][]string) ([]models.SurveyImportRow, error) {
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	known := make(map[string]bool, len(models.SurveyImportColumns))
+	for _, c := range models.SurveyImportColumns {
+		known[c] = true
+	}
+	headers := make([]string, len(records[0]))
+	present := make(map[string]bool, len(headers))
+	var unknown []string
+	for i, h := range records[0] {
+		headers[i] = models.NormalizeImportHeader(h)
+		if headers[i] == "" {
+			continue
+		}
+		if !known[headers[i]] {
+			unknown = append(unknown, h)
+		}
+		present[headers[i]] = true
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown columns: %s; accepted columns are %s",
+			strings.Join(unknown, ", "), strings.Join(models.SurveyImportColumns, ", "))
+	}
+	var missing []string
+	for _, c := range models.SurveyImportRequiredColumns {
+		if !present[c] {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing columns: %s", strings.Join(missing, ", "))
+	}
+
+	rows := make([]models.SurveyImportRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		values := make(map[string]string, len(headers))
+		empty := true
+		for j, cell := range record {
+			if j >= len(headers) || headers[j] == "" {
+				continue
+			}
+			values[headers[j]] = cell
+			if strings.TrimSpace(cell) != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		rows = append(rows, models.SurveyImportRow{Line: i + 2, Values: values})
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("file has no survey rows")
+	}
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("at most %d rows can be imported at once, got %d", maxImportRows, len(rows))
+	}
+	return rows, nil
+}
+
+// importLookup resolves program and region names to IDs, caching every answer
+// since import rows mostly repeat the same few names.
+type importLookup struct {
+	db    *gorm.DB
+	cache map[string]importLookupResult
+}
+
+type importLookupResult struct {
+	id  uint
+	err error
+}
+
+func newImportLookup(db *gorm.DB) *importLookup {
+	return &importLookup{db: db, cache: map[string]importLookupResult{}}
+}
+
+// resolve fills the IDs of the row input. Each name is looked up under its parent
+// (a district within its province, a program within its resource, ...).
+func (l *importLookup) resolve(row *models.SurveyImportRow) {
+	find := func(column, table, parentColumn string, parentID uint) uint {
+		name := strings.TrimSpace(row.Values[column])
+		if name == "" {
+			return 0 // reported by Validate as a missing ID
+		}
+		if parentColumn != "" && parentID == 0 {
+			return 0 // the parent could not be resolved, already reported
+		}
+		id, err := l.find(table, parentColumn, parentID, name)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("%s '%s' %v", strings.ReplaceAll(column, "_", " "), name, err))
+		}
+		return id
+	}
+
+	in := &row.Input
+	in.ProgramTypeID = find("program_type", "program_types", "", 0)
+	in.ResourceID = find("resource", "resources", "program_type_id", in.ProgramTypeID)
+	in.ProgramID = find("program", "programs", "resource_id", in.ResourceID)
+	in.ProvinceID = find("province", "provinces", "", 0)
+	in.DistrictID = find("district", "districts", "province_id", in.ProvinceID)
+	in.SubdistrictID = find("subdistrict", "subdistricts", "district_id", in.DistrictID)
+	in.VillageID = find("village", "villages", "subdistrict_id", in.SubdistrictID)
+}
+
+func (l *importLookup) find(table, parentColumn string, parentID uint, name string) (uint, error) {
+	key := fmt.Sprintf("%s|%d|%s", table, parentID, strings.ToLower(name))
+	if res, ok := l.cache[key]; ok {
+		return res.id, res.err
+	}
+
+	query := l.db.Table(table).Where("deleted_at IS NULL AND LOWER(TRIM(name)) = ?", strings.ToLower(name))
+	if parentColumn != "" {
+		query = query.Where(parentColumn+" = ?", parentID)
+	}
+	var ids []uint
+	var res importLookupResult
+	if err := query.Limit(2).Pluck("id", &ids).Error; err != nil {
+		res.err = errors.New("could not be looked up")
+	} else if len(ids) == 0 {
+		res.err = errors.New("not found")
+	} else if len(ids) > 1 {
+		res.err = errors.New("is ambiguous")
+	} else {
+		res.id = ids[0]
+	}
+	l.cache[key] = res
+	return res.id, res.err
+}
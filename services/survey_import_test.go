@@ -0,0 +1,70 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseImportRows(t *testing.T) {
+	header := []string{
+		"Name", "Address", "Type", "MBR Status", "Year", "Unit Target", "Status Realization",
+		"Program Type", "Resource", "Program", "Province", "District", "Subdistrict", "Village",
+	}
+	row := func(name string) []string {
+		return []string{name, "Jl. Merdeka 1", "Tapak", "MBR", "2024", "1", "Proses",
+			"Baru", "APBN", "BSPS", "Jawa Barat", "Bandung", "Coblong", "Dago"}
+	}
+
+	tests := []struct {
+		name    string
+		records [][]string
+		lines   []int  // spreadsheet lines of the parsed rows
+		err     string // part of the expected error
+	}{
+		{name: "empty file", records: nil, err: "file is empty"},
+		{name: "header only", records: [][]string{header}, err: "no survey rows"},
+		{
+			name:    "unknown column",
+			records: [][]string{append(append([]string{}, header...), "Colour"), row("A")},
+			err:     "unknown columns: Colour",
+		},
+		{name: "missing column", records: [][]string{header[1:], row("A")[1:]}, err: "missing columns: name"},
+		{name: "rows with line numbers", records: [][]string{header, row("A"), row("B")}, lines: []int{2, 3}},
+		{
+			name:    "empty rows skipped",
+			records: [][]string{header, row("A"), {"", " "}, row("B")},
+			lines:   []int{2, 4},
+		},
+		{
+			name:    "blank header column ignored",
+			records: [][]string{append(append([]string{}, header...), ""), append(row("A"), "note")},
+			lines:   []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := parseImportRows(tt.records)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := make([]int, len(rows))
+			for i, r := range rows {
+				lines[i] = r.Line
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %v, want %v", lines, tt.lines)
+			}
+			if rows[0].Values["mbr_status"] != "MBR" {
+				t.Errorf("values not keyed by normalized header: %v", rows[0].Values)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// surveyNoteThreads loads the verification notes of a survey with their replies, oldest first
+func surveyNoteThreads(db *gorm.DB, surveyID uint) ([]models.SurveyNoteResponse, error) {
+	var notes []models.SurveyNote
+	if err := db.Where("survey_id = ?", surveyID).Order("created_at ASC, id ASC").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return models.ToSurveyNoteThreads(notes), nil
+}
+
+// GetSurveyNotes lists the notes verifiers left on a survey in every round, with the replies
+func (s *surveyService) GetSurveyNotes(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var survey models.Survey
+	if err := s.dataScope(ctx).Survey(s.Db, id).First(&survey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Survey not found")
+		}
+		return models.InternalServerErrorResponse("Failed to retrieve survey")
+	}
+
+	threads, err := surveyNoteThreads(s.Db, survey.ID)
+	if err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey notes")
+	}
+	return models.OkResponse(fiber.StatusOK, "Survey notes retrieved successfully", threads)
+}
+
+// ReplySurveyNote lets the owner of a survey answer a note a verifier left on it. The
+// verifier who left the note is notified.
+func (s *surveyService) ReplySurveyNote(ctx *fiber.Ctx, id, noteID string, input models.SurveyNoteReplyInput) models.ServiceResponse {
+	action := "REPLY_SURVEY_NOTE"
+	input.Body = strings.TrimSpace(input.Body)
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	actor := getWorkflowActor(ctx)
+
+	var survey models.Survey
+	if err := s.dataScope(ctx).Survey(s.Db, id).First(&survey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Survey not found")
+		}
+		return models.InternalServerErrorResponse("Failed to retrieve survey")
+	}
+	if survey.UserID != actor.ID {
+		return models.ForbiddenResponse("Only the surveyor of the survey can reply to its notes")
+	}
+
+	var parent models.SurveyNote
+	if err := s.Db.Where("id = ? AND survey_id = ?", noteID, survey.ID).First(&parent).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Note not found")
+		}
+		return models.InternalServerErrorResponse("Failed to retrieve note")
+	}
+	if !parent.IsVerifierNote() {
+		return models.BadRequestResponse("Replies can only be made to a verifier's note")
+	}
+
+	now := time.Now()
+	reply := models.SurveyNote{
+		SurveyID:    survey.ID,
+		ParentID:    &parent.ID,
+		Level:       shared.LevelSurveyor,
+		Action:      shared.ActionReply,
+		Round:       survey.RevisionRound,
+		AuthorID:    actor.ID,
+		AuthorEmail: actor.Email,
+		AuthorRole:  actor.Role,
+		Body:        input.Body,
+		CreatedAt:   now,
+	}
+	if err := s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&reply).Error; err != nil {
+			return err
+		}
+		if parent.AuthorID == 0 {
+			return nil
+		}
+		return tx.Create(&models.Notification{
+			UserID:    parent.AuthorID,
+			Type:      models.NotificationNoteReply,
+			Title:     "Reply to your verification note",
+			Message:   fmt.Sprintf("%s replied to your note on survey %q", actor.Email, survey.Name),
+			SurveyID:  &survey.ID,
+			CreatedAt: now,
+		}).Error
+	}); err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to save reply")
+	}
+
+	utils.LogAudit(ctx, action, fmt.Sprintf("reply to note %d of survey %d", parent.ID, survey.ID))
+	return models.OkResponse(fiber.StatusCreated, "Reply saved", reply.ToResponse())
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+
+	"github.com/google/uuid"
+)
+
+// surveyPhotos describes the survey images with their thumbnails and EXIF data, flagging
+// photos whose GPS is far from the survey coordinate or that were taken before the survey year.
+// Images that are not upload IDs (stored before uploads existed) are left out.
+func (s *surveyService) surveyPhotos(survey *models.Survey, images []string) ([]models.SurveyPhoto, error) {
+	ids := make([]string, 0, len(images))
+	for _, id := range images {
+		if _, err := uuid.Parse(id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	photos := make([]models.SurveyPhoto, 0, len(ids))
+	if len(ids) == 0 {
+		return photos, nil
+	}
+
+	var metadata []models.ImageMetadata
+	if err := s.Db.Where("upload_id IN ?", ids).Find(&metadata).Error; err != nil {
+		return nil, err
+	}
+	var thumbnails []models.UploadThumbnail
+	if err := s.Db.Where("upload_id IN ?", ids).Find(&thumbnails).Error; err != nil {
+		return nil, err
+	}
+
+	metadataByUpload := make(map[string]*models.ImageMetadata, len(metadata))
+	for i := range metadata {
+		metadataByUpload[metadata[i].UploadID] = &metadata[i]
+	}
+	thumbnailsByUpload := make(map[string][]models.UploadThumbnail, len(ids))
+	for _, t := range thumbnails {
+		thumbnailsByUpload[t.UploadID] = append(thumbnailsByUpload[t.UploadID], t)
+	}
+
+	hasCoordinate := survey.Latitude != nil && survey.Longitude != nil
+	for _, id := range ids {
+		photo := models.NewSurveyPhoto(id, metadataByUpload[id], thumbnailsByUpload[id])
+		if photo.Latitude != nil && photo.Longitude != nil && hasCoordinate {
+			distance := shared.DistanceMeters(*survey.Latitude, *survey.Longitude, *photo.Latitude, *photo.Longitude)
+			photo.DistanceMeters = &distance
+			photo.GPSMismatch = distance > s.Config.Photo.MaxDistanceMeters
+		}
+		if photo.CapturedAt != nil && survey.Year > 0 {
+			photo.TakenBeforeYear = photo.CapturedAt.Year() < int(survey.Year)
+		}
+		photos = append(photos, photo)
+	}
+	return photos, nil
+}
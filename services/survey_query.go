@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"housing-survey-api/models"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// applySurveyFilters applies the query string filters shared by the survey list and its exports
+func applySurveyFilters(ctx *fiber.Ctx, db *gorm.DB) (*gorm.DB, error) {
+	return applySurveyFiltersWith(ctx, db, models.SurveyStatusSQL)
+}
+
+// applySurveyFiltersWith applies the survey filters using statusSQL as the derived status,
+// so they also work on survey_statistics. Filters on columns the statistics do not have
+// must be listed in liveOnlyFilters.
+func applySurveyFiltersWith(ctx *fiber.Ctx, db *gorm.DB, statusSQL string) (*gorm.DB, error) {
+	// Filtering
+	if address := ctx.Query("address"); address != "" {
+		db = db.Where("surveys.address LIKE ?", "%"+address+"%")
+	}
+	if userId := ctx.Query("user_id"); userId != "" {
+		db = db.Where("surveys.user_id = ?", userId)
+	}
+	if types := ctx.Query("types"); types != "" {
+		// Assuming types is a comma-separated list of survey types
+		typeList := utils.SplitAndTrim(types, ",")
+		if len(typeList) > 0 {
+			db = db.Where("surveys.type IN ?", typeList)
+		}
+	}
+	if provinceIDs := ctx.Query("province_ids"); provinceIDs != "" {
+		// Assuming province_ids is a comma-separated list of province IDs
+		provinceIDList := utils.SplitAndTrim(provinceIDs, ",")
+		if len(provinceIDList) > 0 {
+			db = db.Where("surveys.province_id IN ?", provinceIDList)
+		}
+	}
+	if districtIDs := ctx.Query("district_ids"); districtIDs != "" {
+		// Assuming district_ids is a comma-separated list of district IDs
+		districtIDList := utils.SplitAndTrim(districtIDs, ",")
+		if len(districtIDList) > 0 {
+			db = db.Where("surveys.district_id IN ?", districtIDList)
+		}
+	}
+	if subdistrictIDs := ctx.Query("subdistrict_ids"); subdistrictIDs != "" {
+		// Assuming subdistrict_ids is a comma-separated list of subdistrict IDs
+		subdistrictIDList := utils.SplitAndTrim(subdistrictIDs, ",")
+		if len(subdistrictIDList) > 0 {
+			db = db.Where("surveys.subdistrict_id IN ?", subdistrictIDList)
+		}
+	}
+	if villageIDs := ctx.Query("village_ids"); villageIDs != "" {
+		// Assuming village_ids is a comma-separated list of village IDs
+		villageIDList := utils.SplitAndTrim(villageIDs, ",")
+		if len(villageIDList) > 0 {
+			db = db.Where("surveys.village_id IN ?", villageIDList)
+		}
+	}
+	if programTypeIDs := ctx.Query("program_type_ids"); programTypeIDs != "" {
+		// Assuming program_type_ids is a comma-separated list of program type IDs
+		programTypeIDList := utils.SplitAndTrim(programTypeIDs, ",")
+		if len(programTypeIDList) > 0 {
+			db = db.Where("surveys.program_type_id IN ?", programTypeIDList)
+		}
+	}
+	if resourceIDs := ctx.Query("resource_ids"); resourceIDs != "" {
+		// Assuming resource_ids is a comma-separated list of resource IDs
+		resourceIDList := utils.SplitAndTrim(resourceIDs, ",")
+		if len(resourceIDList) > 0 {
+			db = db.Where("surveys.resource_id IN ?", resourceIDList)
+		}
+	}
+	if programIDs := ctx.Query("program_ids"); programIDs != "" {
+		// Assuming program_ids is a comma-separated list of program IDs
+		programIDList := utils.SplitAndTrim(programIDs, ",")
+		if len(programIDList) > 0 {
+			db = db.Where("surveys.program_id IN ?", programIDList)
+		}
+	}
+	if years := ctx.Query("years"); years != "" {
+		// Assuming years is a comma-separated list of survey years
+		yearList := utils.SplitAndTrim(years, ",")
+		for _, y := range yearList {
+			if _, err := strconv.ParseUint(y, 10, 32); err != nil {
+				return db, fmt.Errorf("invalid year '%s'", y)
+			}
+		}
+		if len(yearList) > 0 {
+			db = db.Where("surveys.year IN ?", yearList)
+		}
+	}
+	if statuses := ctx.Query("statuses"); statuses != "" {
+		// Assuming statuses is a comma-separated list of derived statuses, see Survey.GetStatusSurvey
+		statusList := utils.SplitAndTrim(statuses, ",")
+		for _, st := range statusList {
+			if !models.IsSurveyStatus(st) {
+				return db, fmt.Errorf("invalid status '%s'", st)
+			}
+		}
+		if len(statusList) > 0 {
+			db = db.Where(statusSQL+" IN ?", statusList)
+		}
+	}
+	return applySpatialFilters(ctx, db)
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// queueStatus is the derived status of the surveys waiting on a workflow level,
+// empty for levels that do not verify
+func queueStatus(level string) string {
+	switch level {
+	case shared.LevelBalai:
+		return shared.StatusWaitingBalai
+	case shared.LevelEselon1:
+		return shared.StatusWaitingEselon1
+	}
+	return ""
+}
+
+// GetQueue lists the surveys waiting on the caller's verification level, longest waiting
+// first. Surveys claimed by another verifier are left out unless include_claimed=true.
+// Surveys Eselon 1 returned to the Balai come first, flagged by returned_at; returned=true
+// or false keeps only those or only the others.
+func (s *surveyService) GetQueue(ctx *fiber.Ctx) models.ServiceResponse {
+	actor := getWorkflowActor(ctx)
+	status := queueStatus(s.Config.Roles.WorkflowLevel(actor.Role))
+	if status == "" {
+		return models.ForbiddenResponse("Only verifiers have a work queue")
+	}
+
+	db := s.dataScope(ctx).Surveys(s.Db.Model(&models.Survey{}))
+	db, err := applySurveyFilters(ctx, db)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	db = db.Where(models.SurveyStatusSQL+" = ?", status)
+	if !ctx.QueryBool("include_claimed", false) {
+		db = db.Where("surveys.claimed_by IS NULL OR surveys.claimed_by = ? OR surveys.claim_expires_at <= ?", actor.ID, time.Now())
+	}
+	if ctx.Query("returned") != "" {
+		if ctx.QueryBool("returned") {
+			db = db.Where("surveys.returned_at IS NOT NULL")
+		} else {
+			db = db.Where("surveys.returned_at IS NULL")
+		}
+	}
+
+	page, err := strconv.Atoi(ctx.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(ctx.Query("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to count queued surveys")
+	}
+	var surveys []models.Survey
+	if err := db.Preload("User").
+		Preload("ProgramType").Preload("Resource").Preload("Program").
+		Preload("Province").Preload("District").Preload("Subdistrict").Preload("Village").
+		Limit(limit).Offset(offset).
+		Order("surveys.returned_at IS NULL, COALESCE(surveys.status_changed_at, surveys.submitted_at, surveys.created_at) ASC, surveys.id ASC").
+		Find(&surveys).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve queued surveys")
+	}
+
+	return models.OkResponse(fiber.StatusOK, "Queue retrieved successfully", fiber.Map{
+		"data":       models.ToSurveyResponse(surveys),
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": int((total + int64(limit) - 1) / int64(limit)),
+	})
+}
+
+// lockQueuedSurvey locks a survey the actor may see that waits on the actor's level
+func (s *surveyService) lockQueuedSurvey(ctx *fiber.Ctx, tx *gorm.DB, id string, actor workflowActor) (models.Survey, error) {
+	var survey models.Survey
+	level := s.Config.Roles.WorkflowLevel(actor.Role)
+	status := queueStatus(level)
+	if status == "" {
+		return survey, fmt.Errorf("%w: only verifiers can claim surveys", ErrTransitionForbidden)
+	}
+	if err := s.dataScope(ctx).Survey(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id).First(&survey).Error; err != nil {
+		return survey, err
+	}
+	if current := survey.GetStatusSurvey(); current != status {
+		return survey, fmt.Errorf("%w: survey is not waiting for %s verification (%s)", ErrIllegalTransition, level, current)
+	}
+	return survey, nil
+}
+
+// saveClaim stores the claim columns only, a claim is not a change of the survey
+func saveClaim(tx *gorm.DB, survey *models.Survey) error {
+	return tx.Model(survey).Select("claimed_by", "claimed_at", "claim_expires_at").UpdateColumns(survey).Error
+}
+
+// ClaimSurvey locks a queued survey for the caller for the configured time, so other
+// verifiers skip it. Claiming it again extends the claim.
+func (s *surveyService) ClaimSurvey(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	action := "CLAIM_SURVEY"
+	actor := getWorkflowActor(ctx)
+	var survey models.Survey
+	err := s.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if survey, err = s.lockQueuedSurvey(ctx, tx, id, actor); err != nil {
+			return err
+		}
+		now := time.Now()
+		if survey.IsClaimedByOther(actor.ID, now) {
+			return fmt.Errorf("%w until %s", ErrSurveyClaimed, survey.ClaimExpiresAt.Format(time.RFC3339))
+		}
+		survey.Claim(actor.ID, now, s.Config.Queue.ClaimTTL)
+		return saveClaim(tx, &survey)
+	})
+	if err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return workflowErrorResponse(err, "Failed to claim survey")
+	}
+
+	utils.LogAudit(ctx, action, fmt.Sprintf("survey %d claimed until %s", survey.ID, survey.ClaimExpiresAt.Format(time.RFC3339)))
+	return models.OkResponse(fiber.StatusOK, "Survey claimed successfully", survey.ToResponse())
+}
+
+// ReleaseSurvey gives up the caller's claim and puts the survey back in the queue
+func (s *surveyService) ReleaseSurvey(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	action := "RELEASE_SURVEY"
+	actor := getWorkflowActor(ctx)
+	var survey models.Survey
+	err := s.Db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if survey, err = s.lockQueuedSurvey(ctx, tx, id, actor); err != nil {
+			return err
+		}
+		if survey.ClaimedBy == nil || *survey.ClaimedBy != actor.ID {
+			return fmt.Errorf("%w: survey is not claimed by you", ErrTransitionForbidden)
+		}
+		survey.ReleaseClaim()
+		return saveClaim(tx, &survey)
+	})
+	if err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return workflowErrorResponse(err, "Failed to release survey")
+	}
+
+	utils.LogAudit(ctx, action, fmt.Sprintf("survey %d released", survey.ID))
+	return models.OkResponse(fiber.StatusOK, "Survey released successfully", survey.ToResponse())
+}
+
+// ReleaseExpiredSurveyClaims clears the claims past their expiry. Expired claims already
+// stop blocking other verifiers, this keeps the claim columns telling the truth.
+func ReleaseExpiredSurveyClaims(ctx context.Context, db *gorm.DB) error {
+	res := db.WithContext(ctx).Model(&models.Survey{}).
+		Where("claim_expires_at <= ?", time.Now()).
+		UpdateColumns(map[string]interface{}{"claimed_by": nil, "claimed_at": nil, "claim_expires_at": nil})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("🔓 Released %d expired survey claims", res.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"testing"
+
+	"housing-survey-api/shared"
+)
+
+func TestQueueStatus(t *testing.T) {
+	want := map[string]string{
+		shared.LevelBalai:    shared.StatusWaitingBalai,
+		shared.LevelEselon1:  shared.StatusWaitingEselon1,
+		shared.LevelSurveyor: "",
+		"":                   "",
+	}
+	for level, status := range want {
+		if got := queueStatus(level); got != status {
+			t.Errorf("queueStatus(%q) = %q, want %q", level, got, status)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"housing-survey-api/config"
@@ -8,12 +9,13 @@ import (
 	"housing-survey-api/models"
 	"housing-survey-api/shared"
 	"housing-survey-api/utils"
-	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SurveyService interface {
@@ -23,20 +25,37 @@ type SurveyService interface {
 	UpdateSurvey(ctx *fiber.Ctx, survey models.SurveyInput) models.ServiceResponse
 	DeleteSurvey(ctx *fiber.Ctx, id string) models.ServiceResponse
 	ActionSurvey(ctx *fiber.Ctx, input models.SurveyActionInput) models.ServiceResponse
+	GetSurveyTimeline(ctx *fiber.Ctx, id string) models.ServiceResponse
+	ResubmitSurvey(ctx *fiber.Ctx, id string, input models.SurveyResubmitInput) models.ServiceResponse
+	RequestCorrection(ctx *fiber.Ctx, id string, input models.SurveyCorrectionInput) models.ServiceResponse
+	ReturnSurvey(ctx *fiber.Ctx, id string, input models.SurveyReturnInput) models.ServiceResponse
+	GetSurveyNotes(ctx *fiber.Ctx, id string) models.ServiceResponse
+	ReplySurveyNote(ctx *fiber.Ctx, id, noteID string, input models.SurveyNoteReplyInput) models.ServiceResponse
+	GetQueue(ctx *fiber.Ctx) models.ServiceResponse
+	ClaimSurvey(ctx *fiber.Ctx, id string) models.ServiceResponse
+	ReleaseSurvey(ctx *fiber.Ctx, id string) models.ServiceResponse
+	GetSurveyVersions(ctx *fiber.Ctx, id string) models.ServiceResponse
+	DiffSurveyVersions(ctx *fiber.Ctx, id, from, to string) models.ServiceResponse
+	ImportSurveys(ctx *fiber.Ctx) models.ServiceResponse
+	ExportSurveys(ctx *fiber.Ctx, format string) (func(w *bufio.Writer), models.ServiceResponse)
+	ExportSurveysGeoJSON(ctx *fiber.Ctx) (func(w *bufio.Writer), models.ServiceResponse)
 	GetSurveysByResource(ctx *fiber.Ctx) models.ServiceResponse
 	GetSurveysByProgramType(ctx *fiber.Ctx) models.ServiceResponse
 	GetSurveysByVerificationStatus(ctx *fiber.Ctx) models.ServiceResponse
+	GetSurveysByStatus(ctx *fiber.Ctx) models.ServiceResponse
 }
 
 type surveyService struct {
-	Db     *gorm.DB
-	Config *config.Config
+	Db       *gorm.DB
+	Config   *config.Config
+	Workflow *surveyWorkflow
 }
 
 func NewSurveyService(ctx *context.AppContext) SurveyService {
 	return &surveyService{
-		Db:     ctx.DB,
-		Config: ctx.Config,
+		Db:       ctx.DB,
+		Config:   ctx.Config,
+		Workflow: &surveyWorkflow{Config: ctx.Config},
 	}
 }
 
@@ -44,97 +63,10 @@ func (s *surveyService) GetAllSurveys(ctx *fiber.Ctx) models.ServiceResponse {
 	var surveys []models.Survey
 	db := s.Db.Model(&models.Survey{})
 
-	// 1. Ambil role & user id (fallback ke "public" kalau ga login)
-	actorRole := "public"
-	actorId := uint(0)
-	var actor models.User
-
-	// Coba ambil dari JWT context, kalau gagal role public
-	if role, err := utils.GetRoleNameFromContext(ctx); err == nil {
-		actorRole = role
-	}
-	if id, err := utils.GetUserIDFromContext(ctx); err == nil {
-		actorId = uint(id)
-		// Kalau user login, ambil sekalian profile-nya
-		if err := s.Db.Preload("Profile").Where("id = ?", actorId).First(&actor).Error; err != nil {
-			// biarin, nanti handle di bawah
-		}
-	}
-
-	// 2. Query role-based filter
-	switch actorRole {
-	case s.Config.Roles.Surveyor:
-		db = db.Where("user_id = ?", actorId)
-	case s.Config.Roles.VerificatorBalai, s.Config.Roles.AdminBalai:
-		if actor.Profile.ID != 0 {
-			db = db.Joins("JOIN profiles ON profiles.user_id = surveys.user_id").
-				Where("profiles.balai_id = ?", actor.Profile.BalaiID)
-		}
-		// case "public", "superadmin", "verificator_eselon1", "admin_eselon1" → akses semua data, tidak perlu filter khusus
-	}
-
-	// Filtering
-	if address := ctx.Query("address"); address != "" {
-		db = db.Where("address LIKE ?", "%"+address+"%")
-	}
-	if userId := ctx.Query("user_id"); userId != "" {
-		db = db.Where("user_id = ?", userId)
-	}
-	if types := ctx.Query("types"); types != "" {
-		// Assuming types is a comma-separated list of survey types
-		typeList := utils.SplitAndTrim(types, ",")
-		if len(typeList) > 0 {
-			db = db.Where("type IN ?", typeList)
-		}
-	}
-	if provinceIDs := ctx.Query("province_ids"); provinceIDs != "" {
-		// Assuming province_ids is a comma-separated list of province IDs
-		provinceIDList := utils.SplitAndTrim(provinceIDs, ",")
-		if len(provinceIDList) > 0 {
-			db = db.Where("province_id IN ?", provinceIDList)
-		}
-	}
-	if districtIDs := ctx.Query("district_ids"); districtIDs != "" {
-		// Assuming district_ids is a comma-separated list of district IDs
-		districtIDList := utils.SplitAndTrim(districtIDs, ",")
-		if len(districtIDList) > 0 {
-			db = db.Where("district_id IN ?", districtIDList)
-		}
-	}
-	if subdistrictIDs := ctx.Query("subdistrict_ids"); subdistrictIDs != "" {
-		// Assuming subdistrict_ids is a comma-separated list of subdistrict IDs
-		subdistrictIDList := utils.SplitAndTrim(subdistrictIDs, ",")
-		if len(subdistrictIDList) > 0 {
-			db = db.Where("subdistrict_id IN ?", subdistrictIDList)
-		}
-	}
-	if villageIDs := ctx.Query("village_ids"); villageIDs != "" {
-		// Assuming village_ids is a comma-separated list of village IDs
-		villageIDList := utils.SplitAndTrim(villageIDs, ",")
-		if len(villageIDList) > 0 {
-			db = db.Where("village_id IN ?", villageIDList)
-		}
-	}
-	if programTypeIDs := ctx.Query("program_type_ids"); programTypeIDs != "" {
-		// Assuming program_type_ids is a comma-separated list of program type IDs
-		programTypeIDList := utils.SplitAndTrim(programTypeIDs, ",")
-		if len(programTypeIDList) > 0 {
-			db = db.Where("program_type_id IN ?", programTypeIDList)
-		}
-	}
-	if resourceIDs := ctx.Query("resource_ids"); resourceIDs != "" {
-		// Assuming resource_ids is a comma-separated list of resource IDs
-		resourceIDList := utils.SplitAndTrim(resourceIDs, ",")
-		if len(resourceIDList) > 0 {
-			db = db.Where("resource_id IN ?", resourceIDList)
-		}
-	}
-	if programIDs := ctx.Query("program_ids"); programIDs != "" {
-		// Assuming program_ids is a comma-separated list of program IDs
-		programIDList := utils.SplitAndTrim(programIDs, ",")
-		if len(programIDList) > 0 {
-			db = db.Where("program_id IN ?", programIDList)
-		}
+	db = s.dataScope(ctx).Surveys(db)
+	db, err := applySurveyFilters(ctx, db)
+	if err != nil {
+		return models.BadRequestResponse(err.Error())
 	}
 
 	// Pagination
@@ -153,13 +85,10 @@ func (s *surveyService) GetAllSurveys(ctx *fiber.Ctx) models.ServiceResponse {
 	if err := db.Count(&total).Error; err != nil {
 		return models.InternalServerErrorResponse("Failed to count surveys")
 	}
-	fmt.Println(db.ToSQL(func(tx *gorm.DB) *gorm.DB {
-		return tx.Preload("User").Limit(limit).Offset(offset).Order("created_at desc").Find(&surveys)
-	}))
 	if err := db.Preload("User").
 		Preload("ProgramType").Preload("Resource").Preload("Program").
 		Preload("Province").Preload("District").Preload("Subdistrict").Preload("Village").
-		Limit(limit).Offset(offset).Order("created_at desc").
+		Limit(limit).Offset(offset).Order("surveys.created_at desc").
 		Find(&surveys).Error; err != nil {
 		return models.InternalServerErrorResponse("Failed to retrieve surveys")
 	}
@@ -175,16 +104,35 @@ func (s *surveyService) GetAllSurveys(ctx *fiber.Ctx) models.ServiceResponse {
 
 func (s *surveyService) GetSurveyDetail(ctx *fiber.Ctx, id string) models.ServiceResponse {
 	var survey models.Survey
-	if err := s.Db.Preload("User").
+	if err := s.dataScope(ctx).Survey(s.Db.Preload("User").
 		Preload("ProgramType").Preload("Resource").Preload("Program").
-		Preload("Province").Preload("District").Preload("Subdistrict").Preload("Village").
-		Where("id = ?", id).First(&survey).Error; err != nil {
+		Preload("Province").Preload("District").Preload("Subdistrict").Preload("Village"), id).
+		First(&survey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Survey not found")
+		}
 		return models.InternalServerErrorResponse("Failed to retrieve survey")
 	}
-	if &survey == nil {
-		return models.NotFoundResponse("Survey not found")
+
+	res := survey.ToResponse()
+	var err error
+	if res.PhotosBefore, err = s.surveyPhotos(&survey, survey.ImagesBefore); err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey photos")
+	}
+	if res.PhotosAfter, err = s.surveyPhotos(&survey, survey.ImagesAfter); err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey photos")
+	}
+	var checklists []models.SurveyChecklist
+	if err := s.Db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	}).Where("survey_id = ?", survey.ID).Order("created_at ASC, id ASC").Find(&checklists).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey checklists")
+	}
+	res.Checklists = models.ToSurveyChecklistResponses(checklists)
+	if res.NoteThreads, err = surveyNoteThreads(s.Db, survey.ID); err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey notes")
 	}
-	return models.OkResponse(fiber.StatusOK, "Survey retrieved successfully", survey.ToResponse())
+	return models.OkResponse(fiber.StatusOK, "Survey retrieved successfully", res)
 }
 
 func (s *surveyService) CreateSurvey(ctx *fiber.Ctx, input models.SurveyInput) models.ServiceResponse {
@@ -193,30 +141,64 @@ func (s *surveyService) CreateSurvey(ctx *fiber.Ctx, input models.SurveyInput) m
 	if err := input.Validate(); err != nil {
 		return models.BadRequestResponse(err.Error())
 	}
-	survey := input.ToSurvey()
-
 	userID, err := utils.GetUserIDFromContext(ctx)
 	if err != nil {
 		return models.InternalServerErrorResponse("Cannot find UserID in token")
 	}
+	if err := validateUploadIDs(s.Db, input.Images, uint(userID), 0); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	survey := input.ToSurvey()
+
 	utils.LogAudit(ctx, "START", "API entered")
 	if userID != int(survey.UserID) {
 		return models.BadRequestResponse("Cannot create survey for another user")
 	}
 
-	// Insert into DB
-	if err := s.Db.Create(&survey).Error; err != nil {
+	// Insert into DB, as a draft first then submitted if requested
+	actor := getWorkflowActor(ctx)
+	if err := s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := s.insertSurvey(tx, &survey, input.IsSubmitted, actor); err != nil {
+			return err
+		}
+		if input.AssignmentID != 0 {
+			return fulfilAssignment(tx, input.AssignmentID, &survey)
+		}
+		return nil
+	}); err != nil {
 		utils.LogAudit(ctx, "CREATE_SURVEY", err.Error())
-		return models.InternalServerErrorResponse("Failed to create survey")
+		return workflowErrorResponse(err, "Failed to create survey")
 	}
 
 	return models.OkResponse(fiber.StatusCreated, "Survey created successfully", survey.ToResponse())
 }
 
+// insertSurvey creates a survey as a draft with its first version, then submits it if requested
+func (s *surveyService) insertSurvey(tx *gorm.DB, survey *models.Survey, submit bool, actor workflowActor) error {
+	if err := tx.Create(survey).Error; err != nil {
+		return err
+	}
+	if _, err := s.Workflow.Transition(tx, survey, shared.ActionCreate, actor, ""); err != nil {
+		return err
+	}
+	if err := s.recordVersion(tx, survey, nil, shared.ActionCreate, actor); err != nil {
+		return err
+	}
+	if submit {
+		if _, err := s.Workflow.Transition(tx, survey, shared.ActionSubmit, actor, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *surveyService) UpdateSurvey(ctx *fiber.Ctx, survey models.SurveyInput) models.ServiceResponse {
 	//enforcing role surveyor only will be in middleware
 	//newSurvey := survey.ToSurvey()
 	oldSurvey := models.Survey{}
+	if err := survey.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
 
 	userID, err := utils.GetUserIDFromContext(ctx)
 	if err != nil {
@@ -226,18 +208,56 @@ func (s *surveyService) UpdateSurvey(ctx *fiber.Ctx, survey models.SurveyInput)
 	if userID != int(survey.UserID) {
 		return models.BadRequestResponse("Cannot update survey for another user")
 	}
+	if err := validateUploadIDs(s.Db, survey.Images, uint(userID), survey.ID); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
 
-	if err := s.Db.Where("id = ? AND deleted_at IS NULL", survey.ID).First(&oldSurvey).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return models.NotFoundResponse("Survey not found")
+	// The survey row stays locked until the version is written, so concurrent edits
+	// are applied one after the other and never compute the same version number
+	var refused models.ServiceResponse
+	errRefused := errors.New("update refused")
+	actor := getWorkflowActor(ctx)
+	scope := s.dataScope(ctx)
+	err = s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := scope.Survey(tx.Clauses(clause.Locking{Strength: "UPDATE"}), survey.ID).First(&oldSurvey).Error; err != nil {
+			return err
 		}
-		return models.InternalServerErrorResponse("Failed to retrieve survey for update")
-	}
+		if userID != int(oldSurvey.UserID) {
+			refused = models.BadRequestResponse("Cannot update survey for another user")
+			return errRefused
+		}
+		if reason := oldSurvey.EditLockReason(); reason != "" {
+			utils.LogAudit(ctx, "SURVEY_LOCKED", fmt.Sprintf("update of survey %d blocked: %s", oldSurvey.ID, reason))
+			refused = models.ForbiddenResponse(reason)
+			return errRefused
+		}
+		if oldSurvey.IsSubmitted && !survey.IsSubmitted {
+			refused = models.BadRequestResponse("Submitted survey cannot be returned to draft")
+			return errRefused
+		}
+		submit := survey.IsSubmitted && !oldSurvey.IsSubmitted
 
-	// Insert into DB
-	oldSurvey.UpdateFromInput(survey)
-	if err := s.Db.Save(&oldSurvey).Error; err != nil {
-		return models.InternalServerErrorResponse("Failed to update survey")
+		previous := oldSurvey.Snapshot()
+		oldSurvey.UpdateFromInput(survey)
+		if err := tx.Save(&oldSurvey).Error; err != nil {
+			return err
+		}
+		if err := s.recordVersion(tx, &oldSurvey, &previous, shared.ActionUpdate, actor); err != nil {
+			return err
+		}
+		if submit {
+			if _, err := s.Workflow.Transition(tx, &oldSurvey, shared.ActionSubmit, actor, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errRefused) {
+		return refused
+	}
+	if err != nil {
+		utils.LogAudit(ctx, "UPDATE_SURVEY", err.Error())
+		return workflowErrorResponse(err, "Failed to update survey")
 	}
 
 	return models.OkResponse(fiber.StatusCreated, "Survey created successfully", oldSurvey.ToResponse())
@@ -250,7 +270,7 @@ func (s *surveyService) DeleteSurvey(ctx *fiber.Ctx, id string) models.ServiceRe
 	}
 
 	var survey models.Survey
-	if err = s.Db.Where("id = ? AND deleted_at IS NULL", id).First(&survey).Error; err != nil {
+	if err = s.dataScope(ctx).Survey(s.Db, id).First(&survey).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return models.NotFoundResponse(fmt.Sprintf("Survey with id %s not found", id))
 		}
@@ -260,6 +280,10 @@ func (s *surveyService) DeleteSurvey(ctx *fiber.Ctx, id string) models.ServiceRe
 	if userID != int(survey.UserID) {
 		return models.BadRequestResponse("Cannot delete survey for another user")
 	}
+	if reason := survey.EditLockReason(); reason != "" {
+		utils.LogAudit(ctx, "SURVEY_LOCKED", fmt.Sprintf("delete of survey %d blocked: %s", survey.ID, reason))
+		return models.ForbiddenResponse(reason)
+	}
 
 	survey.DeletedBy = fmt.Sprint(userID)
 	survey.DeletedAt = gorm.DeletedAt{
@@ -273,292 +297,144 @@ func (s *surveyService) DeleteSurvey(ctx *fiber.Ctx, id string) models.ServiceRe
 	return models.OkResponse(200, "Survey deleted successfully", nil)
 }
 
-func (s *surveyService) ActionSurvey(ctx *fiber.Ctx, input models.SurveyActionInput) models.ServiceResponse {
-	if err := input.Validate(); err != nil {
-		return models.BadRequestResponse(err.Error())
-	}
-
-	role, err := utils.GetRoleNameFromContext(ctx)
-	if err != nil {
-		return models.InternalServerErrorResponse("Cannot determine role")
-	}
-
-	isVerificatorBalai := role == s.Config.Roles.VerificatorBalai
-	isVerificatorEselon1 := role == s.Config.Roles.VerificatorEselon1
-
-	if !isVerificatorBalai && !isVerificatorEselon1 {
-		return models.ForbiddenResponse("You are not allowed to perform this action")
-	}
-
-	// Base query
-	db := s.Db.Model(&models.Survey{}).
-		Where("id IN ?", input.SurveyIDs).
-		Where("is_submitted = ? AND deleted_at IS NULL", true)
-
-	// Filter based on role
-	if isVerificatorBalai {
-		db = db.Where("status_balai = ?", shared.Pending)
-	} else if isVerificatorEselon1 {
-		db = db.Where("status_balai = ? AND status_eselon1 = ?", shared.Approved, shared.Pending)
-	}
-
-	// Prepare update map
-	update := map[string]interface{}{}
-	if input.Action == shared.Rejected {
-		update["notes"] = input.Notes
-	}
-	if isVerificatorBalai {
-		update["status_balai"] = input.Action
-	} else {
-		update["status_eselon1"] = input.Action
-	}
-
-	// Perform update in one query
-	result := db.Updates(update)
-	if result.Error != nil {
-		return models.InternalServerErrorResponse("Failed to update surveys")
-	}
-
-	// Calculate counts
-	successCount := result.RowsAffected
-	failedCount := int64(len(input.SurveyIDs)) - successCount
-
-	return models.OkResponse(fiber.StatusOK, fmt.Sprintf(
-		"%s %d survey(s), %d failed", input.Action, successCount, failedCount,
-	), fiber.Map{
-		"success_count": successCount,
-		"failed_count":  failedCount,
-	})
-}
-
-func (s *surveyService) GetSurveysByResource(ctx *fiber.Ctx) models.ServiceResponse {
-	action := "DASHBOARD_RESOURCE"
-	actorRole, err := utils.GetRoleNameFromContext(ctx)
-	if err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Cannot get RoleID from context")
-	}
-	actorId, err := utils.GetUserIDFromContext(ctx)
+func (s *surveyService) ResubmitSurvey(ctx *fiber.Ctx, id string, input models.SurveyResubmitInput) models.ServiceResponse {
+	action := "RESUBMIT_SURVEY"
+	userID, err := utils.GetUserIDFromContext(ctx)
 	if err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Cannot get UserID from context")
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
 	}
 
-	var actor models.User
-	if err = s.Db.Preload("Profile").Where("id = ?", actorId).First(&actor).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.LogAudit(ctx, action, err.Error())
-			return models.NotFoundResponse("User not found")
+	var survey models.Survey
+	var rejections []models.SurveyStatusHistory
+	scope := s.dataScope(ctx)
+	err = s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := scope.Survey(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id).First(&survey).Error; err != nil {
+			return err
 		}
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Error retrieving user")
-	}
-
-	// 1. Ambil semua resource (buat map tag -> name)
-	var resources []models.Resource
-	if err = s.Db.Find(&resources).Error; err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Error retrieving resources")
-	}
-	// Map tag ke name
-	tagToName := make(map[string]string)
-	for _, r := range resources {
-		// hanya isi jika belum ada (biar ambil yang pertama/utama)
-		if _, ok := tagToName[r.Tag]; !ok {
-			tagToName[r.Tag] = r.Name
+		if userID != int(survey.UserID) {
+			return fmt.Errorf("%w: cannot resubmit survey for another user", ErrTransitionForbidden)
 		}
-	}
-
-	// 2. Hitung survey per tag (bukan per resource_id lagi)
-	tagCount := make(map[string]int64)
-	for _, r := range resources {
-		db := s.Db.Model(&models.Survey{})
-		switch actorRole {
-		case s.Config.Roles.Surveyor:
-			db = db.Where("user_id = ?", actorId)
-		case s.Config.Roles.VerificatorBalai, s.Config.Roles.AdminBalai:
-			db = db.Joins("JOIN profiles ON profiles.user_id = surveys.user_id").
-				Where("profiles.balai_id = ?", actor.Profile.BalaiID)
+		if status := survey.GetStatusSurvey(); models.IsSurveyPending(status) {
+			return fmt.Errorf("%w: survey is still pending verification (%s)", ErrIllegalTransition, status)
 		}
-		var resCount int64
-		if err := db.Where("resource_id = ?", r.ID).Count(&resCount).Error; err != nil {
-			utils.LogAudit(ctx, action, err.Error())
-			return models.InternalServerErrorResponse("cannot count surveys by resource tag")
+		if _, err := s.Workflow.Transition(tx, &survey, shared.ActionResubmit, getWorkflowActor(ctx), input.Notes); err != nil {
+			return err
 		}
-		tagCount[r.Tag] += resCount // group by tag
-	}
-
-	// 3. List tag utama (kalau mau urutan tertentu, bisa manual array ["negara", ...])
-	listTag := []string{"negara", "pengembang", "swadaya", "gotongroyong"}
 
-	// 4. Siapkan hasil output (name diambil dari tagToName, total dari tagCount)
-	var result []models.DashboardResource
-	for _, tag := range listTag {
-		result = append(result, models.DashboardResource{
-			Name:  tagToName[tag],
-			Total: tagCount[tag],
-		})
+		// Rejection reasons of every earlier round stay in the history
+		return tx.Where("survey_id = ? AND action = ?", survey.ID, shared.Rejected).
+			Order("created_at ASC").Find(&rejections).Error
+	})
+	if err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return workflowErrorResponse(err, "Failed to resubmit survey")
 	}
 
-	utils.LogAudit(ctx, action, "Success")
-	return models.OkResponse(200, "Success", result)
+	utils.LogAudit(ctx, action, fmt.Sprintf("survey %d resubmitted, round %d", survey.ID, survey.RevisionRound))
+	return models.OkResponse(fiber.StatusOK, "Survey resubmitted successfully", fiber.Map{
+		"survey":     survey.ToResponse(),
+		"rejections": models.ToSurveyStatusHistoryResponses(rejections),
+	})
 }
 
-func (s *surveyService) GetSurveysByProgramType(ctx *fiber.Ctx) models.ServiceResponse {
-	action := "DASHBOARD_PROGRAM_TYPE"
-	actorRole, err := utils.GetRoleNameFromContext(ctx)
-	if err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Cannot get RoleID from context")
+// RequestCorrection changes a verified survey and sends it back through verification
+func (s *surveyService) RequestCorrection(ctx *fiber.Ctx, id string, input models.SurveyCorrectionInput) models.ServiceResponse {
+	action := "CORRECTION_SURVEY"
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
 	}
-	actorId, err := utils.GetUserIDFromContext(ctx)
+	userID, err := utils.GetUserIDFromContext(ctx)
 	if err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Cannot get UserID from context")
+		return models.InternalServerErrorResponse("Cannot find UserID in token")
 	}
-
-	var actor models.User
-	if err = s.Db.Preload("Profile").Where("id = ?", actorId).First(&actor).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.LogAudit(ctx, action, err.Error())
-			return models.NotFoundResponse("User not found")
-		}
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Error retrieving user")
+	surveyID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return models.BadRequestResponse("Invalid survey ID")
 	}
-
-	var programTypes []models.ProgramType
-	if err = s.Db.Find(&programTypes).Error; err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Error retrieving program types")
+	if err := validateUploadIDs(s.Db, input.Survey.Images, uint(userID), uint(surveyID)); err != nil {
+		return models.BadRequestResponse(err.Error())
 	}
 
-	// --- Tambah: Hitung total survey (untuk persentase)
-	var totalSurvey int64
-	dbAll := s.Db.Model(&models.Survey{})
-	switch actorRole {
-	case s.Config.Roles.Surveyor:
-		dbAll = dbAll.Where("user_id = ?", actorId)
-	case s.Config.Roles.VerificatorBalai, s.Config.Roles.AdminBalai:
-		dbAll = dbAll.Joins("JOIN profiles ON profiles.user_id = surveys.user_id").
-			Where("profiles.balai_id = ?", actor.Profile.BalaiID)
-	}
-	if err := dbAll.Count(&totalSurvey).Error; err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Error counting total surveys")
-	}
-	// ---
-
-	var result []models.DashboardProgramType
-	for _, pt := range programTypes {
-		var resCount int64
-		db := s.Db.Model(&models.Survey{})
-
-		switch actorRole {
-		case s.Config.Roles.Surveyor:
-			db.Where("user_id = ?", actorId)
-		case s.Config.Roles.VerificatorBalai, s.Config.Roles.AdminBalai:
-			db = db.Joins("JOIN profiles ON profiles.user_id = surveys.user_id").
-				Where("profiles.balai_id = ?", actor.Profile.BalaiID)
-			//case s.Config.Roles.VerificatorEselon1, s.Config.Roles.AdminEselon1:
+	var survey models.Survey
+	scope := s.dataScope(ctx)
+	err = s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := scope.Survey(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id).First(&survey).Error; err != nil {
+			return err
 		}
-
-		if err = db.Model(&models.Survey{}).Where("program_type_id = ?", pt.ID).Count(&resCount).Error; err != nil {
-			utils.LogAudit(ctx, action, err.Error())
-			return models.InternalServerErrorResponse("cannot count surveys by resource")
+		if userID != int(survey.UserID) || userID != int(input.Survey.UserID) {
+			return fmt.Errorf("%w: cannot request correction for another user's survey", ErrTransitionForbidden)
 		}
-
-		percent := 0.0
-		if totalSurvey > 0 {
-			percent = (float64(resCount) / float64(totalSurvey)) * 100
-			percent = math.Round(percent*10) / 10 // Satu angka di belakang koma
+		if status := survey.GetStatusSurvey(); status != shared.StatusVerified {
+			return fmt.Errorf("%w: correction requests are only for verified surveys, use update instead (%s)", ErrIllegalTransition, status)
 		}
 
-		result = append(result, models.DashboardProgramType{
-			Name:    pt.Name,
-			Total:   int(resCount),
-			Percent: percent,
-		})
+		actor := getWorkflowActor(ctx)
+		previous := survey.Snapshot()
+		input.Survey.ID = survey.ID
+		survey.UpdateFromInput(input.Survey)
+		if err := tx.Save(&survey).Error; err != nil {
+			return err
+		}
+		if err := s.recordVersion(tx, &survey, &previous, shared.ActionCorrect, actor); err != nil {
+			return err
+		}
+		_, err := s.Workflow.Transition(tx, &survey, shared.ActionCorrect, actor, input.Reason)
+		return err
+	})
+	if err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return workflowErrorResponse(err, "Failed to request survey correction")
 	}
 
-	utils.LogAudit(ctx, action, "Success")
-	return models.OkResponse(200, "Success", result)
+	utils.LogAudit(ctx, action, fmt.Sprintf("survey %d corrected, round %d", survey.ID, survey.RevisionRound))
+	return models.OkResponse(fiber.StatusOK, "Survey correction submitted for verification", survey.ToResponse())
 }
 
-func (s *surveyService) GetSurveysByVerificationStatus(ctx *fiber.Ctx) models.ServiceResponse {
-	action := "DASHBOARD_VERIFIED"
-
-	// 1. Ambil role dan user_id dari context
-	actorRole, err := utils.GetRoleNameFromContext(ctx)
-	if err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Cannot get RoleID from context")
-	}
-	actorId, err := utils.GetUserIDFromContext(ctx)
-	if err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Cannot get UserID from context")
+// ReturnSurvey sends a survey waiting on Eselon 1 back to the Balai for a recheck. Unlike a
+// rejection the surveyor has nothing to do; the survey is returned to the Balai work queue.
+func (s *surveyService) ReturnSurvey(ctx *fiber.Ctx, id string, input models.SurveyReturnInput) models.ServiceResponse {
+	action := "RETURN_SURVEY"
+	input.Notes = strings.TrimSpace(input.Notes)
+	if err := input.Validate(); err != nil {
+		return models.BadRequestResponse(err.Error())
 	}
 
-	// 2. Ambil data profil actor
-	var actor models.User
-	if err = s.Db.Preload("Profile").Where("id = ?", actorId).First(&actor).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.LogAudit(ctx, action, err.Error())
-			return models.NotFoundResponse("User not found")
+	var survey models.Survey
+	scope := s.dataScope(ctx)
+	err := s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := scope.Survey(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id).First(&survey).Error; err != nil {
+			return err
 		}
+		_, err := s.Workflow.Transition(tx, &survey, shared.ActionReturn, getWorkflowActor(ctx), input.Notes)
+		return err
+	})
+	if err != nil {
 		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Error retrieving user")
-	}
-
-	// 3. Mulai Query dan Filter berdasarkan role actor
-	// 3.1 Query untuk mengambil data survey
-	db := s.Db.Model(&models.Survey{})
-
-	// 3.2 Filter berdasarkan role actor
-	switch actorRole {
-	case s.Config.Roles.Surveyor:
-		// 3.2.a. Jika Surveyor, hanya survey yang dibuat olehnya
-		db.Where("user_id = ?", actorId)
-	case s.Config.Roles.VerificatorBalai, s.Config.Roles.AdminBalai:
-		// 3.2.b. Jika Verificator Balai atau Admin Balai, tampilkan data Balai
-		db.Joins("JOIN profiles ON profiles.user_id = surveys.user_id").
-			Where("profiles.balai_id = ?", actor.Profile.BalaiID)
-	case s.Config.Roles.VerificatorEselon1, s.Config.Roles.AdminEselon1:
-		// 3.2.c. Jika Verificator Eselon1 atau Admin Eselon1, tampilkan semua data
-	}
-
-	// 4. Hitung total survey (semua status)
-	var totalSurvey int64
-	if err := db.Count(&totalSurvey).Error; err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Failed to count all surveys")
+		return workflowErrorResponse(err, "Failed to return survey")
 	}
 
-	// 5. Hitung yang sudah diverifikasi oleh Eselon 1
-	var totalSurveyVerified int64
-	dbVerified := db.Session(&gorm.Session{}) // copy semua filter di atas
-	if err := dbVerified.Where("status_eselon1 = ?", "Approved").Count(&totalSurveyVerified).Error; err != nil {
-		utils.LogAudit(ctx, action, err.Error())
-		return models.InternalServerErrorResponse("Failed to count verified surveys")
-	}
+	utils.LogAudit(ctx, action, fmt.Sprintf("survey %d returned to Balai", survey.ID))
+	return models.OkResponse(fiber.StatusOK, "Survey returned to Balai", survey.ToResponse())
+}
 
-	// 6. Hitung persentase (dalam persen, dua desimal)
-	var percentVerified float64 = 0
-	if totalSurvey > 0 {
-		percentVerified = (float64(totalSurveyVerified) / float64(totalSurvey)) * 100
-		percentVerified = math.Round(percentVerified*10) / 10 // satu angka dibelakang koma
+func (s *surveyService) GetSurveyTimeline(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var survey models.Survey
+	if err := s.dataScope(ctx).Survey(s.Db, id).First(&survey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Survey not found")
+		}
+		return models.InternalServerErrorResponse("Failed to retrieve survey")
 	}
 
-	// 7. Bentuk output JSON
-	result := models.DashboardVerified{
-		Name:          "Survey Verified Recap",
-		Total:         int(totalSurvey),
-		VerifiedCount: int(totalSurveyVerified),
-		Percent:       percentVerified,
+	var histories []models.SurveyStatusHistory
+	if err := s.Db.Where("survey_id = ?", survey.ID).
+		Order("created_at ASC, id ASC").Find(&histories).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey timeline")
 	}
 
-	// 8. Log sukses & return response
-	utils.LogAudit(ctx, action, "Success")
-	return models.OkResponse(200, "Success", result)
+	return models.OkResponse(fiber.StatusOK, "Survey timeline retrieved successfully", fiber.Map{
+		"survey_id": survey.ID,
+		"status":    survey.GetStatusSurvey(),
+		"timeline":  models.ToSurveyStatusHistoryResponses(histories),
+	})
 }
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// slaLevels are the workflow levels with a verification SLA, in workflow order
+var slaLevels = []string{shared.LevelBalai, shared.LevelEselon1}
+
+// queueLevel is the workflow level a waiting status waits on, the reverse of queueStatus
+func queueLevel(status string) string {
+	for _, level := range slaLevels {
+		if queueStatus(level) == status {
+			return level
+		}
+	}
+	return ""
+}
+
+// overdueSQL matches the surveys that have waited on one of the levels longer than its
+// SLA at now. It returns an empty condition when none of the levels has an SLA.
+func overdueSQL(cfg config.SLAConfig, levels []string, now time.Time) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+	for _, level := range levels {
+		sla := cfg.For(level)
+		if sla <= 0 {
+			continue
+		}
+		parts = append(parts, "("+models.SurveyStatusSQL+" = ? AND surveys.status_changed_at <= ?)")
+		args = append(args, queueStatus(level), now.Add(-sla))
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+// overdueSelectSQL selects an overdue survey as a models.SLAOverdueRow
+var overdueSelectSQL = "surveys.id AS survey_id, surveys.name AS survey_name, " + models.SurveyStatusSQL + " AS status, " +
+	"owners.email AS surveyor_email, COALESCE(owner_profiles.balai_id, 0) AS balai_id, " +
+	"COALESCE(owner_balais.name, '') AS balai_name, surveys.status_changed_at AS waiting_since, surveys.sla_breached_at"
+
+// joinSurveyOwner joins the owner of each survey with the Balai of their profile
+func joinSurveyOwner(db *gorm.DB) *gorm.DB {
+	return db.Joins("JOIN users owners ON owners.id = surveys.user_id").
+		Joins("LEFT JOIN profiles owner_profiles ON owner_profiles.user_id = surveys.user_id AND owner_profiles.deleted_at IS NULL").
+		Joins("LEFT JOIN balais owner_balais ON owner_balais.id = owner_profiles.balai_id")
+}
+
+// EscalateOverdueSurveys returns the job flagging the surveys that overran the SLA of the
+// level they wait on. Each survey is escalated once per waiting state: for the Balai level
+// the Admin Balai of the surveyor's Balai is notified, for Eselon 1 every Admin Eselon 1.
+// Surveys whose Balai has no Admin Balai are escalated to Admin Eselon 1 instead. An admin
+// with several newly overdue surveys gets a single notification listing how many.
+func EscalateOverdueSurveys(cfg *config.Config) func(ctx context.Context, db *gorm.DB) error {
+	return func(ctx context.Context, db *gorm.DB) error {
+		return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			escalated := 0
+			for _, level := range slaLevels {
+				cond, args := overdueSQL(cfg.SLA, []string{level}, now)
+				if cond == "" {
+					continue
+				}
+				var rows []models.SLAOverdueRow
+				if err := joinSurveyOwner(tx.Model(&models.Survey{})).Select(overdueSelectSQL).
+					Where(cond, args...).Where("surveys.sla_breached_at IS NULL").
+					Scan(&rows).Error; err != nil {
+					return err
+				}
+				if len(rows) == 0 {
+					continue
+				}
+				if err := escalateOverdue(tx, cfg, level, rows, now); err != nil {
+					return err
+				}
+				escalated += len(rows)
+			}
+			if escalated > 0 {
+				log.Printf("⏰ Escalated %d surveys overdue for verification", escalated)
+			}
+			return nil
+		})
+	}
+}
+
+// escalateOverdue notifies the admins in charge of the overdue surveys of a level and flags them
+func escalateOverdue(tx *gorm.DB, cfg *config.Config, level string, rows []models.SLAOverdueRow, now time.Time) error {
+	var eselon1Admins []uint
+	if err := tx.Model(&models.User{}).Joins("JOIN roles ON roles.id = users.role_id").
+		Where("roles.name = ?", cfg.Roles.AdminEselon1).Pluck("users.id", &eselon1Admins).Error; err != nil {
+		return err
+	}
+	balaiAdmins := map[uint][]uint{}
+	if level == shared.LevelBalai {
+		var admins []struct {
+			UserID  uint
+			BalaiID uint
+		}
+		if err := tx.Model(&models.User{}).Select("users.id AS user_id, profiles.balai_id AS balai_id").
+			Joins("JOIN roles ON roles.id = users.role_id").
+			Joins("JOIN profiles ON profiles.user_id = users.id AND profiles.deleted_at IS NULL").
+			Where("roles.name = ? AND profiles.balai_id IS NOT NULL", cfg.Roles.AdminBalai).
+			Scan(&admins).Error; err != nil {
+			return err
+		}
+		for _, a := range admins {
+			balaiAdmins[a.BalaiID] = append(balaiAdmins[a.BalaiID], a.UserID)
+		}
+	}
+
+	// Each admin gets one notification per run, so the first run after the SLA is set up
+	// sums up the surveys already overdue instead of sending one notification each
+	sla := cfg.SLA.For(level)
+	byRecipient := map[uint][]models.SLAOverdueRow{}
+	var recipientOrder []uint
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.SurveyID)
+		recipients := eselon1Admins
+		if level == shared.LevelBalai && len(balaiAdmins[row.BalaiID]) > 0 {
+			recipients = balaiAdmins[row.BalaiID]
+		}
+		for _, userID := range recipients {
+			if _, ok := byRecipient[userID]; !ok {
+				recipientOrder = append(recipientOrder, userID)
+			}
+			byRecipient[userID] = append(byRecipient[userID], row)
+		}
+	}
+	notifications := make([]models.Notification, 0, len(recipientOrder))
+	for _, userID := range recipientOrder {
+		overdue := byRecipient[userID]
+		notification := models.Notification{
+			UserID:    userID,
+			Type:      models.NotificationSLAOverdue,
+			Title:     fmt.Sprintf("%d surveys overdue for %s verification", len(overdue), level),
+			Message:   fmt.Sprintf("%d surveys have waited for %s verification longer than the %s SLA, see the SLA overdue report", len(overdue), level, sla),
+			CreatedAt: now,
+		}
+		if len(overdue) == 1 {
+			row := overdue[0]
+			surveyID := row.SurveyID
+			notification.Title = fmt.Sprintf("Survey overdue for %s verification", level)
+			notification.Message = fmt.Sprintf("Survey %q has waited for %s verification since %s, over the %s SLA",
+				row.SurveyName, level, row.WaitingSince.Format("2006-01-02 15:04"), sla)
+			notification.SurveyID = &surveyID
+		}
+		notifications = append(notifications, notification)
+	}
+	if len(notifications) > 0 {
+		if err := tx.CreateInBatches(notifications, 500).Error; err != nil {
+			return err
+		}
+	}
+	// Flagging is not a change of the survey, updated_at stays
+	return tx.Model(&models.Survey{}).Where("id IN ?", ids).UpdateColumn("sla_breached_at", now).Error
+}
+
+// GetSLAOverdue lists the surveys waiting on a verification level longer than its SLA,
+// longest waiting first, with a count per level and Balai. level=Balai or "Eselon 1" keeps
+// one level. The caller's role and the survey list filters apply.
+func (s *reportService) GetSLAOverdue(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "SLA_OVERDUE_REPORT"
+	levels := slaLevels
+	if level := ctx.Query("level"); level != "" {
+		if queueStatus(level) == "" {
+			return models.BadRequestResponse(fmt.Sprintf("level must be %s or %s", shared.LevelBalai, shared.LevelEselon1))
+		}
+		levels = []string{level}
+	}
+
+	sla := make([]models.SLALevel, 0, len(slaLevels))
+	for _, level := range slaLevels {
+		sla = append(sla, models.SLALevel{Level: level, Hours: s.Config.SLA.For(level).Hours()})
+	}
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+	offset := (page - 1) * limit
+
+	now := time.Now()
+	cond, args := overdueSQL(s.Config.SLA, levels, now)
+	summary := []models.SLAOverdueSummary{}
+	data := []models.SLAOverdueSurvey{}
+	if cond == "" {
+		return models.OkResponse(http.StatusOK, "Success", fiber.Map{
+			"sla": sla, "summary": summary, "data": data, "total": 0, "page": page, "limit": limit, "totalPages": 0,
+		})
+	}
+
+	if _, err := applySurveyFilters(ctx, s.Db.Model(&models.Survey{})); err != nil {
+		return models.BadRequestResponse(err.Error())
+	}
+	scope := newDataScope(ctx, s.Db, s.Config.Roles)
+	query := func() *gorm.DB {
+		db, _ := applySurveyFilters(ctx, scope.Surveys(s.Db.Model(&models.Survey{})))
+		return joinSurveyOwner(db).Where(cond, args...)
+	}
+
+	var groups []models.SLAOverdueSummaryRow
+	if err := query().
+		Select(models.SurveyStatusSQL + " AS status, COALESCE(owner_profiles.balai_id, 0) AS balai_id, " +
+			"COALESCE(owner_balais.name, '') AS balai_name, COUNT(*) AS overdue").
+		Group("1, 2, 3").Order("1, 3").Scan(&groups).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to count overdue surveys")
+	}
+	var total int64
+	for _, g := range groups {
+		summary = append(summary, models.SLAOverdueSummary{
+			Level: queueLevel(g.Status), BalaiID: g.BalaiID, BalaiName: g.BalaiName, Overdue: g.Overdue,
+		})
+		total += g.Overdue
+	}
+
+	var rows []models.SLAOverdueRow
+	if err := query().Select(overdueSelectSQL).
+		Order("surveys.status_changed_at ASC, surveys.id ASC").Limit(limit).Offset(offset).
+		Scan(&rows).Error; err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		return models.InternalServerErrorResponse("Failed to retrieve overdue surveys")
+	}
+	for _, row := range rows {
+		level := queueLevel(row.Status)
+		due := row.WaitingSince.Add(s.Config.SLA.For(level))
+		data = append(data, models.SLAOverdueSurvey{
+			SurveyID:      row.SurveyID,
+			SurveyName:    row.SurveyName,
+			Status:        row.Status,
+			Level:         level,
+			SurveyorEmail: row.SurveyorEmail,
+			BalaiID:       row.BalaiID,
+			BalaiName:     row.BalaiName,
+			WaitingSince:  row.WaitingSince,
+			DueAt:         due,
+			OverdueHours:  math.Round(now.Sub(due).Hours()*10) / 10,
+			EscalatedAt:   row.SLABreachedAt,
+		})
+	}
+
+	return models.OkResponse(http.StatusOK, "Success", fiber.Map{
+		"sla":        sla,
+		"summary":    summary,
+		"data":       data,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
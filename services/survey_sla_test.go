@@ -0,0 +1,54 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/shared"
+)
+
+func TestQueueLevel(t *testing.T) {
+	for _, level := range slaLevels {
+		if got := queueLevel(queueStatus(level)); got != level {
+			t.Errorf("queueLevel(queueStatus(%q)) = %q", level, got)
+		}
+	}
+	for _, status := range []string{shared.StatusDraft, shared.StatusVerified, shared.StatusRejectedBalai} {
+		if got := queueLevel(status); got != "" {
+			t.Errorf("queueLevel(%q) = %q, want none", status, got)
+		}
+	}
+}
+
+func TestOverdueSQL(t *testing.T) {
+	now := time.Date(2025, 5, 10, 12, 0, 0, 0, time.UTC)
+	cfg := config.SLAConfig{Balai: 72 * time.Hour, Eselon1: 120 * time.Hour}
+
+	cond, args := overdueSQL(cfg, slaLevels, now)
+	if strings.Count(cond, "surveys.status_changed_at <= ?") != 2 || !strings.Contains(cond, " OR ") {
+		t.Errorf("condition for both levels: %s", cond)
+	}
+	want := []interface{}{
+		shared.StatusWaitingBalai, now.Add(-72 * time.Hour),
+		shared.StatusWaitingEselon1, now.Add(-120 * time.Hour),
+	}
+	if len(args) != len(want) {
+		t.Fatalf("args = %v, want %v", args, want)
+	}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Errorf("args[%d] = %v, want %v", i, args[i], want[i])
+		}
+	}
+
+	// A level without an SLA is left out, no level with one gives no condition
+	cfg.Balai = 0
+	if _, args := overdueSQL(cfg, slaLevels, now); len(args) != 2 || args[0] != shared.StatusWaitingEselon1 {
+		t.Errorf("Balai SLA disabled: args %v", args)
+	}
+	if cond, args := overdueSQL(cfg, []string{shared.LevelBalai}, now); cond != "" || args != nil {
+		t.Errorf("no SLA: %q %v", cond, args)
+	}
+}
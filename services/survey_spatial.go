@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"housing-survey-api/shared"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultNearRadius = 1000   // meters
+	maxNearRadius     = 500000 // meters
+)
+
+// haversineSQL is the distance in meters between the survey and the point (lat, lat, lng)
+const haversineSQL = "2 * 6371000 * ASIN(SQRT(" +
+	"POWER(SIN(RADIANS(surveys.latitude - ?) / 2), 2) + " +
+	"COS(RADIANS(?)) * COS(RADIANS(surveys.latitude)) * POWER(SIN(RADIANS(surveys.longitude - ?) / 2), 2)))"
+
+// applySpatialFilters narrows the survey query to the map area given by the query parameters
+//   - bbox=minLng,minLat,maxLng,maxLat keeps surveys inside the box
+//   - near=lat,lng&radius=meters keeps surveys within radius of the point (default 1000 m)
+//
+// Surveys without a parsed coordinate never match a spatial filter.
+func applySpatialFilters(ctx *fiber.Ctx, db *gorm.DB) (*gorm.DB, error) {
+	if bbox := ctx.Query("bbox"); bbox != "" {
+		minLng, minLat, maxLng, maxLat, err := shared.ParseBBox(bbox)
+		if err != nil {
+			return db, err
+		}
+		db = db.Where("surveys.latitude BETWEEN ? AND ? AND surveys.longitude BETWEEN ? AND ?",
+			minLat, maxLat, minLng, maxLng)
+	}
+
+	if near := ctx.Query("near"); near != "" {
+		lat, lng, err := shared.ParseCoordinate(near)
+		if err != nil {
+			return db, errors.New("near must be 'lat,lng'")
+		}
+		radius := float64(defaultNearRadius)
+		if r := ctx.Query("radius"); r != "" {
+			radius, err = strconv.ParseFloat(r, 64)
+			if err != nil || radius <= 0 || radius > maxNearRadius {
+				return db, errors.New("radius must be a distance in meters between 0 and 500000")
+			}
+		}
+		// The bounding box uses the lat/lng index, the haversine check trims its corners
+		dLat, dLng := shared.MetersToDegrees(radius, lat)
+		db = db.Where("surveys.latitude BETWEEN ? AND ? AND surveys.longitude BETWEEN ? AND ?",
+			lat-dLat, lat+dLat, lng-dLng, lng+dLng).
+			Where(haversineSQL+" <= ?", lat, lat, lng, radius)
+	} else if ctx.Query("radius") != "" {
+		return db, errors.New("radius requires near=lat,lng")
+	}
+	return db, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// statisticsOverlap re-reads surveys changed shortly before the watermark, so changes
+	// committed by transactions that were still open during the last refresh are not missed
+	statisticsOverlap = 5 * time.Minute
+	// statisticsBatchSize is the number of surveyors rebuilt per statement
+	statisticsBatchSize = 500
+)
+
+// liveOnlyFilters are the survey filters on columns survey_statistics does not have;
+// requests using any of them are answered from the surveys table
+var liveOnlyFilters = []string{"address", "types", "district_ids", "subdistrict_ids", "village_ids", "bbox", "near", "radius"}
+
+// statisticsInsertSQL rebuilds the statistics of the given surveyors from their surveys
+var statisticsInsertSQL = `INSERT INTO survey_statistics (user_id, province_id, program_type_id, resource_id, program_id,
+	year, status, realized_year, realized_month, submitted_year, submitted_month, survey_count, unit_target, budget)
+SELECT surveys.user_id, surveys.province_id, surveys.program_type_id, surveys.resource_id, surveys.program_id,
+	surveys.year, ` + models.SurveyStatusSQL + `,
+	CASE WHEN surveys.status_realization = @realized THEN surveys.year_realization ELSE 0 END,
+	CASE WHEN surveys.status_realization = @realized THEN surveys.month_realization ELSE 0 END,
+	COALESCE(EXTRACT(YEAR FROM surveys.submitted_at)::int, 0),
+	COALESCE(EXTRACT(MONTH FROM surveys.submitted_at)::int, 0),
+	COUNT(*), COALESCE(SUM(surveys.unit_target), 0), COALESCE(SUM(surveys.budget), 0)
+FROM surveys
+WHERE surveys.deleted_at IS NULL AND surveys.user_id IN @user_ids
+GROUP BY 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11`
+
+// RefreshSurveyStatistics rebuilds the statistics of every surveyor with a survey created,
+// changed or deleted since the last refresh. The first refresh builds them all.
+func RefreshSurveyStatistics(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The row lock also keeps concurrent refreshes from interleaving
+		var state models.SurveyStatisticState
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			FirstOrCreate(&state, models.SurveyStatisticState{ID: models.SurveyStatisticStateID}).Error; err != nil {
+			return err
+		}
+		// Start of the transaction, every change after it is left to the next refresh
+		var now time.Time
+		if err := tx.Raw("SELECT now()").Scan(&now).Error; err != nil {
+			return err
+		}
+
+		var userIDs []uint
+		changed := tx.Unscoped().Model(&models.Survey{}).Distinct("user_id")
+		if state.Watermark.IsZero() {
+			if err := tx.Where("1 = 1").Delete(&models.SurveyStatistic{}).Error; err != nil {
+				return err
+			}
+			changed = changed.Where("deleted_at IS NULL")
+		} else {
+			since := state.Watermark.Add(-statisticsOverlap)
+			changed = changed.Where("updated_at >= ? OR created_at >= ? OR deleted_at >= ?", since, since, since)
+		}
+		if err := changed.Pluck("user_id", &userIDs).Error; err != nil {
+			return err
+		}
+
+		for start := 0; start < len(userIDs); start += statisticsBatchSize {
+			batch := userIDs[start:min(start+statisticsBatchSize, len(userIDs))]
+			if err := tx.Where("user_id IN ?", batch).Delete(&models.SurveyStatistic{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(statisticsInsertSQL, map[string]interface{}{
+				"realized": shared.RealizationSelesai,
+				"user_ids": batch,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		state.Watermark, state.RefreshedAt = now, now
+		if err := tx.Save(&state).Error; err != nil {
+			return err
+		}
+		if len(userIDs) > 0 {
+			log.Printf("📊 Survey statistics refreshed for %d surveyors", len(userIDs))
+		}
+		return nil
+	})
+}
+
+// aggregateSource is where dashboards and reports aggregate surveys from: survey_statistics
+// when they are fresh and the request only filters on their columns, the surveys table
+// otherwise. Both are queried as "surveys" with the same column names; survey_statistics
+// rows already hold several surveys, so counts sum survey_count.
+type aggregateSource struct {
+	statistics  bool
+	refreshedAt *time.Time // nil for live data
+	base        func() *gorm.DB
+	build       func() *gorm.DB
+}
+
+// newAggregateSource picks the source for the request and checks its filters
+func newAggregateSource(ctx *fiber.Ctx, conn *gorm.DB, cfg *config.Config) (*aggregateSource, error) {
+	src := &aggregateSource{}
+	if at := statisticsRefreshedAt(ctx, conn, cfg.Statistics); at != nil {
+		src.statistics, src.refreshedAt = true, at
+	}
+	src.base = func() *gorm.DB {
+		if src.statistics {
+			return conn.Table("survey_statistics AS surveys")
+		}
+		return conn.Model(&models.Survey{})
+	}
+
+	// Filters only fail on their input, so checking them once is enough
+	if _, err := applySurveyFiltersWith(ctx, src.base(), src.statusSQL()); err != nil {
+		return nil, err
+	}
+	scope := newDataScope(ctx, conn, cfg.Roles)
+	src.build = func() *gorm.DB {
+		db, _ := applySurveyFiltersWith(ctx, scope.Surveys(src.base()), src.statusSQL())
+		return db
+	}
+	return src, nil
+}
+
+// statisticsRefreshedAt returns when the statistics were refreshed, or nil when the
+// request needs live data: a filter they cannot answer, or statistics never built or
+// older than the configured maximum age.
+func statisticsRefreshedAt(ctx *fiber.Ctx, conn *gorm.DB, cfg config.StatisticsConfig) *time.Time {
+	for _, name := range liveOnlyFilters {
+		if ctx.Query(name) != "" {
+			return nil
+		}
+	}
+	var state models.SurveyStatisticState
+	if err := conn.Where("id = ?", models.SurveyStatisticStateID).Limit(1).Find(&state).Error; err != nil || state.RefreshedAt.IsZero() {
+		return nil
+	}
+	if cfg.MaxAge > 0 && time.Since(state.RefreshedAt) > cfg.MaxAge {
+		return nil
+	}
+	return &state.RefreshedAt
+}
+
+// query returns a new scoped and filtered query, one per statement
+func (a *aggregateSource) query() *gorm.DB {
+	return a.build()
+}
+
+// unscoped returns a new query over all surveys, ignoring the caller's role and filters
+func (a *aggregateSource) unscoped() *gorm.DB {
+	return a.base()
+}
+
+// countSQL is the number of surveys of a group
+func (a *aggregateSource) countSQL() string {
+	if a.statistics {
+		return "COALESCE(SUM(surveys.survey_count), 0)"
+	}
+	return "COUNT(*)"
+}
+
+// statusSQL is the derived status of the surveys of a row, see models.SurveyStatusSQL
+func (a *aggregateSource) statusSQL() string {
+	if a.statistics {
+		return "surveys.status"
+	}
+	return models.SurveyStatusSQL
+}
+
+// aggregateSQL selects the count, units and budget of a group as total, units and budget
+func (a *aggregateSource) aggregateSQL() string {
+	return a.countSQL() + " AS total, COALESCE(SUM(surveys.unit_target), 0) AS units, " +
+		"COALESCE(SUM(surveys.budget), 0) AS budget"
+}
+
+// submittedSQL returns the year and month of first submission, 0 for drafts
+func (a *aggregateSource) submittedSQL() (string, string) {
+	if a.statistics {
+		return "surveys.submitted_year", "surveys.submitted_month"
+	}
+	return "COALESCE(EXTRACT(YEAR FROM surveys.submitted_at)::int, 0)", "COALESCE(EXTRACT(MONTH FROM surveys.submitted_at)::int, 0)"
+}
+
+// realizedSQL returns the realization year and month of realized ("Selesai") surveys, 0 otherwise
+func (a *aggregateSource) realizedSQL() (string, string) {
+	if a.statistics {
+		return "surveys.realized_year", "surveys.realized_month"
+	}
+	return "CASE WHEN surveys.status_realization = '" + shared.RealizationSelesai + "' THEN surveys.year_realization ELSE 0 END",
+		"CASE WHEN surveys.status_realization = '" + shared.RealizationSelesai + "' THEN surveys.month_realization ELSE 0 END"
+}
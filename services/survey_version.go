@@ -0,0 +1,118 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// recordVersion stores a snapshot of the survey as its next version. When a survey
+// created before versioning gets its first version, previous is kept as a baseline.
+// Callers hold the survey row lock (SELECT ... FOR UPDATE) so the next number is theirs.
+func (s *surveyService) recordVersion(tx *gorm.DB, survey *models.Survey, previous *models.SurveySnapshot, action string, actor workflowActor) error {
+	var latest uint
+	if err := tx.Model(&models.SurveyVersion{}).Where("survey_id = ?", survey.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+		return err
+	}
+
+	insert := func(action string, snapshot models.SurveySnapshot) error {
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		latest++
+		version := models.SurveyVersion{
+			SurveyID:   survey.ID,
+			Version:    latest,
+			Action:     action,
+			Snapshot:   data,
+			ActorID:    actor.ID,
+			ActorEmail: actor.Email,
+			CreatedAt:  time.Now(),
+		}
+		return tx.Create(&version).Error
+	}
+
+	if latest == 0 && previous != nil {
+		if err := insert(shared.ActionBaseline, *previous); err != nil {
+			return err
+		}
+	}
+	return insert(action, survey.Snapshot())
+}
+
+func (s *surveyService) GetSurveyVersions(ctx *fiber.Ctx, id string) models.ServiceResponse {
+	var survey models.Survey
+	if err := s.dataScope(ctx).Survey(s.Db, id).First(&survey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NotFoundResponse("Survey not found")
+		}
+		return models.InternalServerErrorResponse("Failed to retrieve survey")
+	}
+
+	var versions []models.SurveyVersion
+	if err := s.Db.Where("survey_id = ?", survey.ID).Order("version ASC").Find(&versions).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey versions")
+	}
+
+	return models.OkResponse(fiber.StatusOK, "Survey versions retrieved successfully", models.ToSurveyVersionResponses(versions))
+}
+
+func (s *surveyService) DiffSurveyVersions(ctx *fiber.Ctx, id, from, to string) models.ServiceResponse {
+	fromVersion, err := strconv.Atoi(from)
+	if err != nil || fromVersion < 1 {
+		return models.BadRequestResponse("Invalid version " + from)
+	}
+	toVersion, err := strconv.Atoi(to)
+	if err != nil || toVersion < 1 {
+		return models.BadRequestResponse("Invalid version " + to)
+	}
+
+	visible, err := s.dataScope(ctx).CanSee(s.Db, id)
+	if err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey")
+	}
+	if !visible {
+		return models.NotFoundResponse("Survey not found")
+	}
+
+	var versions []models.SurveyVersion
+	if err := s.Db.Where("survey_id = ? AND version IN ?", id, []int{fromVersion, toVersion}).
+		Find(&versions).Error; err != nil {
+		return models.InternalServerErrorResponse("Failed to retrieve survey versions")
+	}
+
+	byVersion := make(map[uint]models.SurveyVersion, len(versions))
+	for _, v := range versions {
+		byVersion[v.Version] = v
+	}
+	a, okA := byVersion[uint(fromVersion)]
+	b, okB := byVersion[uint(toVersion)]
+	if !okA || !okB {
+		return models.NotFoundResponse("Survey version not found")
+	}
+
+	snapshotA, err := a.GetSnapshot()
+	if err != nil {
+		return models.InternalServerErrorResponse("Failed to read survey version " + from)
+	}
+	snapshotB, err := b.GetSnapshot()
+	if err != nil {
+		return models.InternalServerErrorResponse("Failed to read survey version " + to)
+	}
+
+	return models.OkResponse(fiber.StatusOK, "Survey version diff retrieved successfully", fiber.Map{
+		"survey_id": a.SurveyID,
+		"from":      a.ToResponse(),
+		"to":        b.ToResponse(),
+		"changes":   models.DiffSurveySnapshots(snapshotA, snapshotB),
+	})
+}
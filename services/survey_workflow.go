@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIllegalTransition   = errors.New("illegal survey status transition")
+	ErrTransitionForbidden = errors.New("role is not allowed to perform this transition")
+	ErrSurveyClaimed       = errors.New("survey is claimed by another verifier")
+)
+
+// workflowActor is the user performing a survey transition
+type workflowActor struct {
+	ID    uint
+	Email string
+	Role  string
+}
+
+func getWorkflowActor(ctx *fiber.Ctx) workflowActor {
+	id, _ := utils.GetUserIDFromContext(ctx)
+	email, _ := utils.GetUserEmailFromContext(ctx)
+	role, _ := utils.GetRoleNameFromContext(ctx)
+	return workflowActor{ID: uint(id), Email: email, Role: role}
+}
+
+// surveyWorkflow moves surveys through models.SurveyTransitions and keeps survey_status_history
+type surveyWorkflow struct {
+	Config *config.Config
+}
+
+// Transition applies the action to the survey, persists its status columns
+// and appends the step to survey_status_history. Run it inside a transaction.
+func (w *surveyWorkflow) Transition(tx *gorm.DB, survey *models.Survey, action string, actor workflowActor, notes string) (models.SurveyTransition, error) {
+	from := ""
+	if action != shared.ActionCreate {
+		from = survey.GetStatusSurvey()
+	}
+
+	t, ok := models.FindSurveyTransition(from, action)
+	if !ok {
+		return t, fmt.Errorf("%w: cannot %s a survey with status '%s'", ErrIllegalTransition, action, from)
+	}
+	if w.Config.Roles.WorkflowLevel(actor.Role) != t.Level {
+		return t, fmt.Errorf("%w: %s requires %s level", ErrTransitionForbidden, action, t.Level)
+	}
+	if survey.IsClaimedByOther(actor.ID, time.Now()) {
+		return t, fmt.Errorf("%w until %s", ErrSurveyClaimed, survey.ClaimExpiresAt.Format(time.RFC3339))
+	}
+
+	survey.ApplyStatus(t.To)
+	// The survey leaves the queue it was claimed in
+	survey.ReleaseClaim()
+	if t.Action == shared.ActionResubmit || t.Action == shared.ActionCorrect {
+		survey.RevisionRound++
+	}
+	// The column holds the notes of the current rejection only, every note is kept in survey_notes
+	survey.Notes = ""
+	if models.IsSurveyRejected(t.To) {
+		survey.Notes = notes
+	}
+	survey.UpdatedBy = fmt.Sprint(actor.ID)
+	survey.UpdatedAt = time.Now()
+	survey.StatusChangedAt = &survey.UpdatedAt
+	survey.SLABreachedAt = nil // the SLA of the new status starts now
+	// A returned survey stays flagged until the Balai acts on it again
+	survey.ReturnedAt, survey.ReturnNotes = nil, ""
+	if t.Action == shared.ActionReturn {
+		survey.ReturnedAt, survey.ReturnNotes = &survey.UpdatedAt, notes
+	}
+	if t.Action == shared.ActionSubmit && survey.SubmittedAt == nil {
+		submittedAt := survey.UpdatedAt
+		survey.SubmittedAt = &submittedAt
+	}
+	if err := tx.Model(survey).
+		Select("is_submitted", "status_balai", "status_eselon1", "revision_round", "notes", "submitted_at",
+			"status_changed_at", "sla_breached_at", "returned_at", "return_notes", "claimed_by", "claimed_at", "claim_expires_at",
+			"updated_by", "updated_at").
+		Updates(survey).Error; err != nil {
+		return t, err
+	}
+
+	history := models.SurveyStatusHistory{
+		SurveyID:   survey.ID,
+		FromStatus: t.From,
+		ToStatus:   t.To,
+		Action:     t.Action,
+		ActorID:    actor.ID,
+		ActorEmail: actor.Email,
+		ActorRole:  actor.Role,
+		Notes:      notes,
+		Round:      survey.RevisionRound,
+		CreatedAt:  time.Now(),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return t, err
+	}
+
+	if notes != "" && t.Level != shared.LevelSurveyor {
+		note := models.SurveyNote{
+			SurveyID:    survey.ID,
+			HistoryID:   &history.ID,
+			Level:       t.Level,
+			Action:      t.Action,
+			Round:       survey.RevisionRound,
+			AuthorID:    actor.ID,
+			AuthorEmail: actor.Email,
+			AuthorRole:  actor.Role,
+			Body:        notes,
+			CreatedAt:   history.CreatedAt,
+		}
+		if err := tx.Create(&note).Error; err != nil {
+			return t, err
+		}
+	}
+	return t, nil
+}
+
+// workflowErrorResponse maps an error from surveyWorkflow.Transition to a service response
+func workflowErrorResponse(err error, fallback string) models.ServiceResponse {
+	switch {
+	case errors.Is(err, ErrIllegalTransition):
+		return models.BadRequestResponse(err.Error())
+	case errors.Is(err, ErrTransitionForbidden):
+		return models.ForbiddenResponse(err.Error())
+	case errors.Is(err, ErrSurveyClaimed):
+		return models.ErrResponse(http.StatusConflict, err.Error())
+	case errors.Is(err, ErrAssignment):
+		return models.BadRequestResponse(err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return models.NotFoundResponse("Survey not found")
+	}
+	return models.InternalServerErrorResponse(fallback)
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunDB builds statements without a database, so Transition can be
+// exercised for its effect on the survey struct
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 port=1", PreferSimpleProtocol: true}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestWorkflowResubmitRounds(t *testing.T) {
+	w := &surveyWorkflow{Config: &config.Config{Roles: config.RolesConfig{
+		Surveyor:           "Surveyor",
+		VerificatorBalai:   "Verificator Balai",
+		VerificatorEselon1: "Verificator Eselon 1",
+	}}}
+	surveyor := workflowActor{ID: 7, Role: "Surveyor"}
+	balai := workflowActor{ID: 8, Role: "Verificator Balai"}
+	eselon1 := workflowActor{ID: 9, Role: "Verificator Eselon 1"}
+	tx := dryRunDB(t)
+
+	survey := models.Survey{ID: 1, UserID: 7}
+	steps := []struct {
+		action string
+		actor  workflowActor
+		round  int
+	}{
+		{shared.ActionCreate, surveyor, 0},
+		{shared.ActionSubmit, surveyor, 0},
+		{shared.Rejected, balai, 0},
+		{shared.ActionResubmit, surveyor, 1},
+		{shared.Approved, balai, 1},
+		{shared.Rejected, eselon1, 1},
+		{shared.ActionResubmit, surveyor, 2},
+		{shared.Approved, eselon1, 2},
+	}
+	for _, s := range steps {
+		if _, err := w.Transition(tx, &survey, s.action, s.actor, "notes"); err != nil {
+			t.Fatalf("%s: %v", s.action, err)
+		}
+		if int(survey.RevisionRound) != s.round {
+			t.Fatalf("after %s: round %d, want %d", s.action, survey.RevisionRound, s.round)
+		}
+	}
+	if got := survey.GetStatusSurvey(); got != shared.StatusVerified {
+		t.Fatalf("final status %q, want %q", got, shared.StatusVerified)
+	}
+
+	// Verified surveys, and surveys still waiting on a verificator, cannot be resubmitted
+	if _, err := w.Transition(tx, &survey, shared.ActionResubmit, surveyor, ""); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("resubmit of verified survey: %v, want ErrIllegalTransition", err)
+	}
+	pending := models.Survey{ID: 2, UserID: 7}
+	pending.ApplyStatus(shared.StatusWaitingEselon1)
+	if _, err := w.Transition(tx, &pending, shared.ActionResubmit, surveyor, ""); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("resubmit of pending survey: %v, want ErrIllegalTransition", err)
+	}
+	if pending.RevisionRound != 0 {
+		t.Errorf("refused resubmit changed round to %d", pending.RevisionRound)
+	}
+
+	rejected := models.Survey{ID: 3, UserID: 7}
+	rejected.ApplyStatus(shared.StatusRejectedBalai)
+	if _, err := w.Transition(tx, &rejected, shared.ActionResubmit, balai, ""); !errors.Is(err, ErrTransitionForbidden) {
+		t.Errorf("resubmit by verificator: %v, want ErrTransitionForbidden", err)
+	}
+}
+
+func TestWorkflowCorrection(t *testing.T) {
+	w := &surveyWorkflow{Config: &config.Config{Roles: config.RolesConfig{Surveyor: "Surveyor"}}}
+	surveyor := workflowActor{ID: 7, Role: "Surveyor"}
+	tx := dryRunDB(t)
+
+	survey := models.Survey{ID: 1, UserID: 7, RevisionRound: 1, Notes: "old rejection"}
+	survey.ApplyStatus(shared.StatusVerified)
+	if _, err := w.Transition(tx, &survey, shared.ActionCorrect, surveyor, "wrong coordinates"); err != nil {
+		t.Fatal(err)
+	}
+	if got := survey.GetStatusSurvey(); got != shared.StatusWaitingBalai {
+		t.Errorf("status after correction %q, want %q", got, shared.StatusWaitingBalai)
+	}
+	if survey.RevisionRound != 2 {
+		t.Errorf("round after correction %d, want 2", survey.RevisionRound)
+	}
+	// The notes column only holds the current rejection, the reason is kept in survey_notes
+	if survey.Notes != "" {
+		t.Errorf("notes after correction %q, want none", survey.Notes)
+	}
+
+	// Only verified surveys take the correction path
+	for _, status := range []string{shared.StatusDraft, shared.StatusWaitingEselon1, shared.StatusRejectedBalai} {
+		other := models.Survey{ID: 2, UserID: 7}
+		other.ApplyStatus(status)
+		if _, err := w.Transition(tx, &other, shared.ActionCorrect, surveyor, ""); !errors.Is(err, ErrIllegalTransition) {
+			t.Errorf("correction from %s: %v, want ErrIllegalTransition", status, err)
+		}
+	}
+}
+
+func TestWorkflowSubmittedAtKeepsFirstSubmission(t *testing.T) {
+	w := &surveyWorkflow{Config: &config.Config{Roles: config.RolesConfig{Surveyor: "Surveyor", VerificatorBalai: "Verificator Balai"}}}
+	surveyor := workflowActor{ID: 7, Role: "Surveyor"}
+	tx := dryRunDB(t)
+
+	survey := models.Survey{ID: 1, UserID: 7}
+	if _, err := w.Transition(tx, &survey, shared.ActionCreate, surveyor, ""); err != nil {
+		t.Fatal(err)
+	}
+	if survey.SubmittedAt != nil {
+		t.Fatalf("draft has submitted_at %v", survey.SubmittedAt)
+	}
+	if _, err := w.Transition(tx, &survey, shared.ActionSubmit, surveyor, ""); err != nil {
+		t.Fatal(err)
+	}
+	if survey.SubmittedAt == nil {
+		t.Fatal("submit did not set submitted_at")
+	}
+	first := *survey.SubmittedAt
+
+	if _, err := w.Transition(tx, &survey, shared.Rejected, workflowActor{ID: 8, Role: "Verificator Balai"}, "blurry photos"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Transition(tx, &survey, shared.ActionResubmit, surveyor, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !survey.SubmittedAt.Equal(first) {
+		t.Errorf("resubmission moved submitted_at from %v to %v", first, survey.SubmittedAt)
+	}
+}
+
+func TestWorkflowRespectsClaims(t *testing.T) {
+	w := &surveyWorkflow{Config: &config.Config{Roles: config.RolesConfig{VerificatorBalai: "Verificator Balai"}}}
+	tx := dryRunDB(t)
+	first := workflowActor{ID: 8, Role: "Verificator Balai"}
+	second := workflowActor{ID: 9, Role: "Verificator Balai"}
+
+	survey := models.Survey{ID: 1, UserID: 7}
+	survey.ApplyStatus(shared.StatusWaitingBalai)
+	survey.Claim(first.ID, time.Now(), time.Hour)
+
+	if _, err := w.Transition(tx, &survey, shared.Approved, second, ""); !errors.Is(err, ErrSurveyClaimed) {
+		t.Fatalf("approval over another verifier's claim: %v, want ErrSurveyClaimed", err)
+	}
+	if got := survey.GetStatusSurvey(); got != shared.StatusWaitingBalai {
+		t.Fatalf("refused approval changed status to %q", got)
+	}
+
+	if _, err := w.Transition(tx, &survey, shared.Approved, first, ""); err != nil {
+		t.Fatal(err)
+	}
+	if survey.ClaimedBy != nil || survey.StatusChangedAt == nil {
+		t.Errorf("after approval: claimed by %v, status changed at %v", survey.ClaimedBy, survey.StatusChangedAt)
+	}
+}
+
+func TestWorkflowReturnToBalai(t *testing.T) {
+	w := &surveyWorkflow{Config: &config.Config{Roles: config.RolesConfig{
+		VerificatorBalai:   "Verificator Balai",
+		VerificatorEselon1: "Verificator Eselon 1",
+	}}}
+	balai := workflowActor{ID: 8, Role: "Verificator Balai"}
+	eselon1 := workflowActor{ID: 9, Role: "Verificator Eselon 1"}
+	tx := dryRunDB(t)
+
+	survey := models.Survey{ID: 1, UserID: 7, Notes: "earlier rejection"}
+	survey.ApplyStatus(shared.StatusWaitingEselon1)
+	if _, err := w.Transition(tx, &survey, shared.ActionReturn, balai, "check the photos"); !errors.Is(err, ErrTransitionForbidden) {
+		t.Fatalf("return by Balai: %v, want ErrTransitionForbidden", err)
+	}
+
+	if _, err := w.Transition(tx, &survey, shared.ActionReturn, eselon1, "check the photos"); err != nil {
+		t.Fatal(err)
+	}
+	if got := survey.GetStatusSurvey(); got != shared.StatusWaitingBalai {
+		t.Errorf("status %q, want %q", got, shared.StatusWaitingBalai)
+	}
+	if survey.ReturnedAt == nil || survey.ReturnNotes != "check the photos" {
+		t.Errorf("return not flagged: %v %q", survey.ReturnedAt, survey.ReturnNotes)
+	}
+	// Nothing is shown to the surveyor as a rejection and no new round starts
+	if survey.Notes != "" || survey.RevisionRound != 0 {
+		t.Errorf("return set rejection notes %q or round %d", survey.Notes, survey.RevisionRound)
+	}
+
+	if _, err := w.Transition(tx, &survey, shared.Approved, balai, ""); err != nil {
+		t.Fatal(err)
+	}
+	if survey.ReturnedAt != nil || survey.ReturnNotes != "" {
+		t.Errorf("Balai approval kept the return flag: %v %q", survey.ReturnedAt, survey.ReturnNotes)
+	}
+}
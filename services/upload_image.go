@@ -0,0 +1,73 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// processImage reads the EXIF metadata of an uploaded image and stores its thumbnails.
+// An image that cannot be decoded keeps its upload without thumbnails; only storage
+// failures are returned as errors. Thumbnails already stored are returned with the
+// error so the caller can clean them up.
+func (s *uploadService) processImage(ctx *fiber.Ctx, fh *multipart.FileHeader, upload models.Upload) (*models.ImageMetadata, []models.UploadThumbnail, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := utils.ReadImageInfo(data)
+	metadata := &models.ImageMetadata{
+		UploadID:    upload.ID,
+		CapturedAt:  info.CapturedAt,
+		Latitude:    info.Latitude,
+		Longitude:   info.Longitude,
+		CameraMake:  info.CameraMake,
+		CameraModel: info.CameraModel,
+		CreatedAt:   time.Now(),
+	}
+
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		utils.LogAudit(ctx, "UPLOAD_THUMBNAIL", fmt.Sprintf("cannot decode %s: %v", upload.ID, err))
+		return metadata, nil, nil
+	}
+	size := utils.UprightSize(img, info.Orientation)
+	metadata.Width, metadata.Height = size.X, size.Y
+
+	thumbnails := make([]models.UploadThumbnail, 0, len(shared.ThumbnailSizes))
+	for name, maxEdge := range shared.ThumbnailSizes {
+		encoded, dims, err := utils.Thumbnail(img, info.Orientation, maxEdge)
+		if err != nil {
+			utils.LogAudit(ctx, "UPLOAD_THUMBNAIL", fmt.Sprintf("cannot encode %s thumbnail of %s: %v", name, upload.ID, err))
+			continue
+		}
+		thumbnail := models.UploadThumbnail{
+			UploadID:  upload.ID,
+			Size:      name,
+			Key:       fmt.Sprintf("thumbnails/%s/%s.jpg", upload.ID, name),
+			Width:     dims.X,
+			Height:    dims.Y,
+			FileSize:  int64(len(encoded)),
+			CreatedAt: time.Now(),
+		}
+		if err := s.Storage.Put(ctx.UserContext(), thumbnail.Key, bytes.NewReader(encoded), thumbnail.FileSize, "image/jpeg"); err != nil {
+			return metadata, thumbnails, err
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+	return metadata, thumbnails, nil
+}
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+
+	"housing-survey-api/config"
+	"housing-survey-api/internal/context"
+	"housing-survey-api/internal/storage"
+	"housing-survey-api/models"
+	"housing-survey-api/shared"
+	"housing-survey-api/utils"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type UploadService interface {
+	Upload(ctx *fiber.Ctx) models.ServiceResponse
+	GetUploadFile(ctx *fiber.Ctx, id, size string) (models.Upload, io.ReadCloser, models.ServiceResponse)
+}
+
+type uploadService struct {
+	Db      *gorm.DB
+	Config  *config.Config
+	Storage storage.Storage
+}
+
+func NewUploadService(ctx *context.AppContext) UploadService {
+	return &uploadService{
+		Db:      ctx.DB,
+		Config:  ctx.Config,
+		Storage: ctx.Storage,
+	}
+}
+
+// Upload stores every file of the multipart field "files" and returns their upload IDs
+func (s *uploadService) Upload(ctx *fiber.Ctx) models.ServiceResponse {
+	action := "UPLOAD_FILE"
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return models.BadRequestResponse("Request must be multipart/form-data")
+	}
+	files := form.File["files"]
+	if len(files) == 0 {
+		return models.BadRequestResponse("At least one file is required in field 'files'")
+	}
+	if len(files) > s.Config.Storage.MaxUploadFiles {
+		return models.BadRequestResponse(fmt.Sprintf("At most %d files can be uploaded at once", s.Config.Storage.MaxUploadFiles))
+	}
+
+	// Check every file before storing any of them
+	mimeTypes := make([]string, len(files))
+	for i, fh := range files {
+		mimeType, err := s.checkFile(fh)
+		if err != nil {
+			utils.LogAudit(ctx, action, err.Error())
+			return models.BadRequestResponse(err.Error())
+		}
+		mimeTypes[i] = mimeType
+	}
+
+	var userID *uint
+	if id, err := utils.GetUserIDFromContext(ctx); err == nil {
+		uid := uint(id)
+		userID = &uid
+	}
+	actor := utils.GetActor(ctx)
+
+	uploads := make([]models.Upload, 0, len(files))
+	var metadata []models.ImageMetadata
+	var thumbnails []models.UploadThumbnail
+	for i, fh := range files {
+		id := uuid.NewString()
+		upload := models.Upload{
+			ID:        id,
+			UserID:    userID,
+			Backend:   s.Storage.Name(),
+			Key:       fmt.Sprintf("uploads/%s/%s%s", time.Now().Format("2006/01"), id, shared.UploadMimeTypes[mimeTypes[i]]),
+			FileName:  fh.Filename,
+			MimeType:  mimeTypes[i],
+			Size:      fh.Size,
+			CreatedBy: actor,
+			CreatedAt: time.Now(),
+		}
+		if err := s.store(ctx, fh, upload); err != nil {
+			utils.LogAudit(ctx, action, err.Error())
+			s.removeStored(ctx, uploads, thumbnails)
+			return models.InternalServerErrorResponse("Failed to store file " + fh.Filename)
+		}
+		uploads = append(uploads, upload)
+
+		meta, thumbs, err := s.processImage(ctx, fh, upload)
+		thumbnails = append(thumbnails, thumbs...)
+		if err != nil {
+			utils.LogAudit(ctx, action, err.Error())
+			s.removeStored(ctx, uploads, thumbnails)
+			return models.InternalServerErrorResponse("Failed to store thumbnails of " + fh.Filename)
+		}
+		if meta != nil {
+			metadata = append(metadata, *meta)
+		}
+	}
+
+	err = s.Db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&uploads).Error; err != nil {
+			return err
+		}
+		if len(metadata) > 0 {
+			if err := tx.Create(&metadata).Error; err != nil {
+				return err
+			}
+		}
+		if len(thumbnails) > 0 {
+			if err := tx.Create(&thumbnails).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		utils.LogAudit(ctx, action, err.Error())
+		s.removeStored(ctx, uploads, thumbnails)
+		return models.InternalServerErrorResponse("Failed to save uploads")
+	}
+
+	return models.OkResponse(fiber.StatusCreated, "Files uploaded successfully", models.ToUploadResponses(uploads))
+}
+
+// checkFile enforces the size limit and sniffs the real content type of an uploaded file
+func (s *uploadService) checkFile(fh *multipart.FileHeader) (string, error) {
+	if fh.Size > s.Config.Storage.MaxUploadSize {
+		return "", fmt.Errorf("%s exceeds the %d MB limit", fh.Filename, s.Config.Storage.MaxUploadSize>>20)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s", fh.Filename)
+	}
+	defer f.Close()
+
+	detected, err := mimetype.DetectReader(f)
+	if err != nil {
+		return "", fmt.Errorf("cannot read %s", fh.Filename)
+	}
+	mimeType := detected.String()
+	if _, ok := shared.UploadMimeTypes[mimeType]; !ok {
+		return "", fmt.Errorf("%s has unsupported type %s", fh.Filename, mimeType)
+	}
+	return mimeType, nil
+}
+
+func (s *uploadService) store(ctx *fiber.Ctx, fh *multipart.FileHeader, upload models.Upload) error {
+	f, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Storage.Put(ctx.UserContext(), upload.Key, f, upload.Size, upload.MimeType)
+}
+
+func (s *uploadService) removeStored(ctx *fiber.Ctx, uploads []models.Upload, thumbnails []models.UploadThumbnail) {
+	keys := make([]string, 0, len(uploads)+len(thumbnails))
+	for _, u := range uploads {
+		keys = append(keys, u.Key)
+	}
+	for _, t := range thumbnails {
+		keys = append(keys, t.Key)
+	}
+	for _, key := range keys {
+		if err := s.Storage.Delete(ctx.UserContext(), key); err != nil {
+			utils.LogAudit(ctx, "UPLOAD_CLEANUP", err.Error())
+		}
+	}
+}
+
+// GetUploadFile opens an upload, or one of its thumbnails when size is set. Uploads
+// stored before thumbnails existed fall back to the original file.
+func (s *uploadService) GetUploadFile(ctx *fiber.Ctx, id, size string) (models.Upload, io.ReadCloser, models.ServiceResponse) {
+	var upload models.Upload
+	if _, err := uuid.Parse(id); err != nil {
+		return upload, nil, models.NotFoundResponse("Upload not found")
+	}
+	if _, ok := shared.ThumbnailSizes[size]; size != "" && !ok {
+		return upload, nil, models.BadRequestResponse("Invalid thumbnail size " + size)
+	}
+	if err := s.Db.Where("id = ?", id).First(&upload).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return upload, nil, models.NotFoundResponse("Upload not found")
+		}
+		return upload, nil, models.InternalServerErrorResponse("Failed to retrieve upload")
+	}
+	if upload.Backend != s.Storage.Name() {
+		return upload, nil, models.InternalServerErrorResponse(fmt.Sprintf("Upload is stored on the %s backend", upload.Backend))
+	}
+	if size != "" {
+		var thumbnail models.UploadThumbnail
+		err := s.Db.Where("upload_id = ? AND size = ?", upload.ID, size).First(&thumbnail).Error
+		if err == nil {
+			upload.Key = thumbnail.Key
+			upload.MimeType = "image/jpeg"
+			upload.Size = thumbnail.FileSize
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return upload, nil, models.InternalServerErrorResponse("Failed to retrieve thumbnail")
+		}
+	}
+
+	reader, err := s.Storage.Get(ctx.UserContext(), upload.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return upload, nil, models.NotFoundResponse("Upload file not found")
+		}
+		utils.LogAudit(ctx, "GET_UPLOAD", err.Error())
+		return upload, nil, models.InternalServerErrorResponse("Failed to read upload")
+	}
+	return upload, reader, models.OkResponse(fiber.StatusOK, "Success", nil)
+}
+
+// validateUploadIDs checks that surveys and comments only reference uploads the caller made,
+// and that no other survey uses them. surveyID is the survey being saved, 0 for a new survey
+// or a comment. The images the survey already has are kept as they are, even when someone
+// else uploaded them or they are base64 images stored before uploads existed; only newly
+// added images must be upload IDs.
+func validateUploadIDs(db *gorm.DB, ids []string, userID, surveyID uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	kept := map[string]bool{}
+	if surveyID != 0 {
+		var survey models.Survey
+		if err := db.Select("id", "images_before", "images_after").Where("id = ?", surveyID).
+			Limit(1).Find(&survey).Error; err != nil {
+			return err
+		}
+		for _, id := range append(survey.ImagesBefore, survey.ImagesAfter...) {
+			kept[id] = true
+		}
+	}
+	added := make([]string, 0, len(ids))
+	for _, id := range uniqueStrings(ids) {
+		if kept[id] {
+			continue
+		}
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("images must be upload IDs, got '%s'", utils.Truncate(id, 40))
+		}
+		added = append(added, id)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	var uploads []models.Upload
+	if err := db.Where("id IN ?", added).Find(&uploads).Error; err != nil {
+		return err
+	}
+	if len(uploads) != len(added) {
+		return errors.New("some images refer to unknown uploads")
+	}
+	for _, upload := range uploads {
+		if upload.UserID == nil || *upload.UserID != userID {
+			return fmt.Errorf("image %s was not uploaded by you", upload.ID)
+		}
+	}
+
+	var linked int64
+	if err := db.Model(&models.Survey{}).
+		Where("id <> ? AND (images_before && ? OR images_after && ?)", surveyID, pq.StringArray(added), pq.StringArray(added)).
+		Count(&linked).Error; err != nil {
+		return err
+	}
+	if linked > 0 {
+		return errors.New("some images are already used by another survey")
+	}
+	return nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
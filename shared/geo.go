@@ -0,0 +1,114 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const earthRadiusMeters = 6371000
+
+// Bounding box of Indonesia, with some margin for the outer islands
+const (
+	IndonesiaMinLat = -11.0
+	IndonesiaMaxLat = 6.5
+	IndonesiaMinLng = 94.0
+	IndonesiaMaxLng = 141.5
+)
+
+var (
+	ErrInvalidCoordinate = errors.New("coordinate must be 'lat,lng' or a GeoJSON Point")
+	ErrInvalidBBox       = errors.New("bbox must be 'minLng,minLat,maxLng,maxLat'")
+)
+
+type geoJSONPoint struct {
+	Type        string        `json:"type"`
+	Coordinates []float64     `json:"coordinates"`
+	Geometry    *geoJSONPoint `json:"geometry"` // set when the point is wrapped in a Feature
+}
+
+// ParseCoordinate reads a survey coordinate stored either as "lat,lng" or as a
+// GeoJSON Point (or Feature holding one), whose coordinates are [lng, lat].
+func ParseCoordinate(s string) (lat, lng float64, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, ErrInvalidCoordinate
+	}
+
+	if strings.HasPrefix(s, "{") {
+		var point geoJSONPoint
+		if err := json.Unmarshal([]byte(s), &point); err != nil {
+			return 0, 0, ErrInvalidCoordinate
+		}
+		if point.Type == "Feature" && point.Geometry != nil {
+			point = *point.Geometry
+		}
+		if point.Type != "Point" || len(point.Coordinates) < 2 {
+			return 0, 0, ErrInvalidCoordinate
+		}
+		lat, lng = point.Coordinates[1], point.Coordinates[0]
+	} else {
+		parts := strings.Split(s, ",")
+		if len(parts) != 2 {
+			return 0, 0, ErrInvalidCoordinate
+		}
+		if lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err != nil {
+			return 0, 0, ErrInvalidCoordinate
+		}
+		if lng, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+			return 0, 0, ErrInvalidCoordinate
+		}
+	}
+
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, ErrInvalidCoordinate
+	}
+	return lat, lng, nil
+}
+
+// DistanceMeters returns the great-circle distance between two points using the haversine formula
+func DistanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// InIndonesia reports whether a point lies within Indonesia's bounding box
+func InIndonesia(lat, lng float64) bool {
+	return lat >= IndonesiaMinLat && lat <= IndonesiaMaxLat && lng >= IndonesiaMinLng && lng <= IndonesiaMaxLng
+}
+
+// ParseBBox reads a bounding box in the GeoJSON order "minLng,minLat,maxLng,maxLat"
+func ParseBBox(s string) (minLng, minLat, maxLng, maxLat float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return 0, 0, 0, 0, ErrInvalidBBox
+	}
+	values := make([]float64, 4)
+	for i, p := range parts {
+		if values[i], err = strconv.ParseFloat(strings.TrimSpace(p), 64); err != nil {
+			return 0, 0, 0, 0, ErrInvalidBBox
+		}
+	}
+	minLng, minLat, maxLng, maxLat = values[0], values[1], values[2], values[3]
+	if minLng > maxLng || minLat > maxLat || minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 {
+		return 0, 0, 0, 0, ErrInvalidBBox
+	}
+	return minLng, minLat, maxLng, maxLat, nil
+}
+
+// MetersToDegrees converts a distance to the latitude and longitude spans it covers around lat,
+// used to narrow radius searches to an indexed bounding box first
+func MetersToDegrees(meters, lat float64) (dLat, dLng float64) {
+	dLat = meters / earthRadiusMeters * 180 / math.Pi
+	cos := math.Cos(lat * math.Pi / 180)
+	if cos < 0.01 {
+		return dLat, 180
+	}
+	return dLat, dLat / cos
+}
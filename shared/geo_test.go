@@ -0,0 +1,105 @@
+package shared
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		lat, lng float64
+		err      error
+	}{
+		{name: "lat,lng", in: "-6.2088,106.8456", lat: -6.2088, lng: 106.8456},
+		{name: "spaces", in: "  -6.2 , 106.8 ", lat: -6.2, lng: 106.8},
+		{name: "geojson point", in: `{"type":"Point","coordinates":[106.8456,-6.2088]}`, lat: -6.2088, lng: 106.8456},
+		{
+			name: "geojson feature",
+			in:   `{"type":"Feature","geometry":{"type":"Point","coordinates":[110.4,-7.0]}}`,
+			lat:  -7.0, lng: 110.4,
+		},
+		{name: "empty", in: "", err: ErrInvalidCoordinate},
+		{name: "one number", in: "-6.2", err: ErrInvalidCoordinate},
+		{name: "three numbers", in: "1,2,3", err: ErrInvalidCoordinate},
+		{name: "not a number", in: "abc,106.8", err: ErrInvalidCoordinate},
+		{name: "latitude out of range", in: "91,106.8", err: ErrInvalidCoordinate},
+		{name: "longitude out of range", in: "-6.2,181", err: ErrInvalidCoordinate},
+		{name: "geojson line", in: `{"type":"LineString","coordinates":[[1,2],[3,4]]}`, err: ErrInvalidCoordinate},
+		{name: "geojson without coordinates", in: `{"type":"Point","coordinates":[106.8]}`, err: ErrInvalidCoordinate},
+		{name: "broken json", in: `{"type":"Point"`, err: ErrInvalidCoordinate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lng, err := ParseCoordinate(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseCoordinate(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if lat != tt.lat || lng != tt.lng {
+				t.Errorf("ParseCoordinate(%q) = %v,%v, want %v,%v", tt.in, lat, lng, tt.lat, tt.lng)
+			}
+		})
+	}
+}
+
+func TestDistanceMeters(t *testing.T) {
+	// Monas to Bundaran HI is a little over 2 km
+	if d := DistanceMeters(-6.1754, 106.8272, -6.1950, 106.8230); math.Abs(d-2230) > 30 {
+		t.Errorf("Monas to Bundaran HI = %.0f m", d)
+	}
+	if d := DistanceMeters(-6.2, 106.8, -6.2, 106.8); d != 0 {
+		t.Errorf("distance to itself = %v", d)
+	}
+	// One degree of latitude is about 111.2 km everywhere
+	if d := DistanceMeters(0, 120, 1, 120); math.Abs(d-111195) > 1 {
+		t.Errorf("one degree of latitude = %.0f m", d)
+	}
+}
+
+func TestParseBBox(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want [4]float64
+		err  error
+	}{
+		{name: "jakarta", in: "106.6,-6.4,107.0,-6.0", want: [4]float64{106.6, -6.4, 107.0, -6.0}},
+		{name: "spaces", in: " 94 , -11 , 141.5 , 6.5 ", want: [4]float64{94, -11, 141.5, 6.5}},
+		{name: "single point", in: "106,-6,106,-6", want: [4]float64{106, -6, 106, -6}},
+		{name: "three numbers", in: "106,-6,107", err: ErrInvalidBBox},
+		{name: "not a number", in: "106,-6,x,-5", err: ErrInvalidBBox},
+		{name: "lat,lng order", in: "-6.4,106.6,-6.0,107.0", err: ErrInvalidBBox},
+		{name: "min above max", in: "107,-6,106,-5", err: ErrInvalidBBox},
+		{name: "latitude out of range", in: "106,-91,107,-6", err: ErrInvalidBBox},
+		{name: "longitude out of range", in: "106,-6,181,-5", err: ErrInvalidBBox},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minLng, minLat, maxLng, maxLat, err := ParseBBox(tt.in)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseBBox(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+			if got := [4]float64{minLng, minLat, maxLng, maxLat}; got != tt.want {
+				t.Errorf("ParseBBox(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetersToDegrees(t *testing.T) {
+	dLat, dLng := MetersToDegrees(111195, 0)
+	if math.Abs(dLat-1) > 1e-3 || math.Abs(dLng-1) > 1e-3 {
+		t.Errorf("111 km at the equator = %v,%v degrees, want about 1,1", dLat, dLng)
+	}
+	// Longitude degrees shrink away from the equator
+	if _, dLng := MetersToDegrees(111195, 60); math.Abs(dLng-2) > 1e-2 {
+		t.Errorf("111 km at 60 degrees = %v degrees of longitude, want about 2", dLng)
+	}
+	if _, dLng := MetersToDegrees(1000, 90); dLng != 180 {
+		t.Errorf("at the pole dLng = %v, want 180", dLng)
+	}
+}
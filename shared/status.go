@@ -15,9 +15,39 @@ var (
 	StatusRealProses  = "Proses Pembangunan"
 	StatusRealSelesai = "Sudah Realisasi"
 
+	RealizationProses  = "Proses"  // Stored value of StatusRealProses
+	RealizationSelesai = "Selesai" // Stored value of StatusRealSelesai
+
+	// UploadMimeTypes maps the accepted upload content types to their file extension
+	UploadMimeTypes = map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/webp": ".webp",
+	}
+
+	// ThumbnailSizes maps the generated thumbnail names to their longest edge in pixels
+	ThumbnailSizes = map[string]int{
+		"small":  160,
+		"medium": 480,
+		"large":  1280,
+	}
+
 	Create = "create"
 	Update = "update"
 
+	ActionCreate   = "Create"     // Survey drafted
+	ActionSubmit   = "Submit"     // Survey submitted for verification
+	ActionResubmit = "Resubmit"   // Rejected survey sent back for verification
+	ActionCorrect  = "Correction" // Verified survey changed through a correction request
+	ActionUpdate   = "Update"     // Survey data edited by its owner
+	ActionBaseline = "Baseline"   // Survey data before versioning started
+	ActionReturn   = "Return"     // Survey sent back by Eselon 1 for the Balai to recheck
+	ActionReply    = "Reply"      // Surveyor reply to a verification note
+
+	LevelSurveyor = "Surveyor" // Survey owner
+	LevelBalai    = "Balai"    // Balai verification level
+	LevelEselon1  = "Eselon 1" // Eselon 1 verification level
+
 	TagNegara       = "Negara"
 	TagPengembang   = "Pengembang"
 	TagSwadaya      = "Swadaya"
@@ -25,6 +55,10 @@ var (
 
 	ListTagResource = []string{TagNegara, TagPengembang, TagSwadaya, TagGotongRoyong}
 
+	AssignmentAssigned  = "Assigned"  // Waiting for the surveyor to accept
+	AssignmentAccepted  = "Accepted"  // Surveyor is working on it
+	AssignmentFulfilled = "Fulfilled" // Linked survey created
+
 	StatusResolved   = "Resolved"   // Status for resolved comments
 	StatusUnresolved = "Unresolved" // Status for unresolved comments
 
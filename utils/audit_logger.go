@@ -32,28 +32,36 @@ func GetActor(c *fiber.Ctx) string {
 }
 
 func LogAudit(c *fiber.Ctx, action, message string) {
+	AuditFunc(c)(action, message)
+}
+
+// AuditFunc captures the request details of c, so entries can still be logged
+// after the handler returned, e.g. from a response stream writer
+func AuditFunc(c *fiber.Ctx) func(action, message string) {
 	userID, _ := GetUserIDFromContext(c)
 	email, _ := GetUserEmailFromContext(c)
 	role, _ := GetRoleNameFromContext(c)
-	ip := c.IP()
-	method := c.Method()
-	url := c.OriginalURL()
-	requestID := c.Get("X-Request-ID")
+	// fiber reuses the request buffers once the handler returns
+	ip := strings.Clone(c.IP())
+	entity := fmt.Sprintf("%s %s", c.Method(), c.OriginalURL())
+	requestID := strings.Clone(c.Get("X-Request-ID"))
 
-	log := models.AuditLog{
-		RequestID: StringPtr(requestID),
-		UserID:    StringPtr(fmt.Sprint(userID)),
-		Email:     StringPtr(email),
-		Role:      StringPtr(role),
-		IP:        StringPtr(ip),
-		Action:    StringPtr(action),
-		Entity:    StringPtr(fmt.Sprintf("%s %s", method, url)),
-		Detail:    StringPtr(message),
-		CreatedAt: time.Now(),
-	}
+	return func(action, message string) {
+		log := models.AuditLog{
+			RequestID: StringPtr(requestID),
+			UserID:    StringPtr(fmt.Sprint(userID)),
+			Email:     StringPtr(email),
+			Role:      StringPtr(role),
+			IP:        StringPtr(ip),
+			Action:    StringPtr(action),
+			Entity:    StringPtr(entity),
+			Detail:    StringPtr(message),
+			CreatedAt: time.Now(),
+		}
 
-	if err := config.DB.Create(&log).Error; err != nil {
-		fmt.Printf("Failed to insert audit log: %v\n", err)
+		if err := config.DB.Create(&log).Error; err != nil {
+			fmt.Printf("Failed to insert audit log: %v\n", err)
+		}
 	}
 }
 
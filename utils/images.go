@@ -1,18 +1,148 @@
 package utils
 
 import (
-	"encoding/base64"
-	"strings"
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // registers the png decoder
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the webp decoder
 )
 
-func IsValidBase64Image(data string) bool {
-	if !strings.HasPrefix(data, "data:image/") {
-		return false
+// ImageInfo is the EXIF data kept from an uploaded photo.
+type ImageInfo struct {
+	CapturedAt  *time.Time
+	Latitude    *float64
+	Longitude   *float64
+	CameraMake  string
+	CameraModel string
+	Orientation int // EXIF orientation, 1 when absent
+}
+
+// ReadImageInfo extracts capture time, GPS position and orientation from EXIF data.
+// Images without EXIF (PNG, most WebP, stripped JPEG) return an empty ImageInfo.
+func ReadImageInfo(data []byte) ImageInfo {
+	info := ImageInfo{Orientation: 1}
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return info
+	}
+
+	if t, err := x.DateTime(); err == nil && !t.IsZero() {
+		info.CapturedAt = &t
+	}
+	if lat, lng, err := x.LatLong(); err == nil && !(lat == 0 && lng == 0) {
+		info.Latitude, info.Longitude = &lat, &lng
+	}
+	if tag, err := x.Get(exif.Make); err == nil {
+		info.CameraMake, _ = tag.StringVal()
+	}
+	if tag, err := x.Get(exif.Model); err == nil {
+		info.CameraModel, _ = tag.StringVal()
+	}
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if o, err := tag.Int(0); err == nil && o >= 1 && o <= 8 {
+			info.Orientation = o
+		}
+	}
+	return info
+}
+
+// MaxImagePixels bounds the images DecodeImage accepts. A small file can declare huge
+// dimensions, and decoding allocates memory for every pixel before looking at the data.
+const MaxImagePixels = 40_000_000
+
+// DecodeImage decodes a JPEG, PNG or WebP image. The header is read first, so other formats
+// and images larger than MaxImagePixels are refused without decoding them.
+func DecodeImage(data []byte) (image.Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format != "jpeg" && format != "png" && format != "webp" {
+		return nil, fmt.Errorf("unsupported image format '%s'", format)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxImagePixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// UprightSize returns the dimensions of img once the EXIF orientation is applied
+func UprightSize(img image.Image, orientation int) image.Point {
+	b := img.Bounds()
+	if orientation >= 5 && orientation <= 8 {
+		return image.Pt(b.Dy(), b.Dx())
+	}
+	return image.Pt(b.Dx(), b.Dy())
+}
+
+// Thumbnail scales img so its longest edge is at most maxEdge pixels, turns it upright
+// following the EXIF orientation and encodes it as JPEG. Images already smaller than
+// maxEdge are re-encoded without upscaling.
+func Thumbnail(img image.Image, orientation, maxEdge int) ([]byte, image.Point, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > maxEdge || h > maxEdge {
+		if w >= h {
+			w, h = maxEdge, max(1, h*maxEdge/w)
+		} else {
+			w, h = max(1, w*maxEdge/h), maxEdge
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+
+	// Rotating after scaling keeps the per-pixel work small
+	upright := orient(dst, orientation)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, upright, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, image.Point{}, err
+	}
+	return buf.Bytes(), image.Pt(upright.Bounds().Dx(), upright.Bounds().Dy()), nil
+}
+
+// orient applies the EXIF orientation so that thumbnails are not shown sideways
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// Orientations 5-8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
 	}
-	parts := strings.SplitN(data, ",", 2)
-	if len(parts) != 2 {
-		return false
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // mirrored and rotated 90 CW
+				dx, dy = y, x
+			case 6: // rotated 90 CW
+				dx, dy = h-1-y, x
+			case 7: // mirrored and rotated 90 CCW
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 CCW
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
 	}
-	_, err := base64.StdEncoding.DecodeString(parts[1])
-	return err == nil
+	return dst
 }
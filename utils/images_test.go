@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		name        string
+		w, h        int
+		orientation int
+		want        image.Point
+	}{
+		{name: "landscape", w: 1200, h: 800, orientation: 1, want: image.Pt(300, 200)},
+		{name: "portrait", w: 800, h: 1200, orientation: 1, want: image.Pt(200, 300)},
+		{name: "small image is not upscaled", w: 120, h: 90, orientation: 1, want: image.Pt(120, 90)},
+		{name: "rotated 90 CW", w: 1200, h: 800, orientation: 6, want: image.Pt(200, 300)},
+		{name: "rotated 180", w: 1200, h: 800, orientation: 3, want: image.Pt(300, 200)},
+		{name: "very thin", w: 3000, h: 2, orientation: 1, want: image.Pt(300, 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+			data, size, err := Thumbnail(img, tt.orientation, 300)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if size != tt.want {
+				t.Errorf("size = %v, want %v", size, tt.want)
+			}
+			decoded, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+			if got := decoded.Bounds().Size(); got != tt.want {
+				t.Errorf("encoded size = %v, want %v", got, tt.want)
+			}
+			if got := UprightSize(img, tt.orientation); tt.orientation == 6 && got != image.Pt(tt.h, tt.w) {
+				t.Errorf("UprightSize = %v, want %v", got, image.Pt(tt.h, tt.w))
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x1 image with a red pixel on the left
+	red := color.RGBA{R: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, red)
+
+	want := map[int]image.Point{
+		1: image.Pt(0, 0),
+		2: image.Pt(1, 0), // mirrored: red moves to the right
+		3: image.Pt(1, 0),
+		6: image.Pt(0, 0), // rotated 90 CW: red ends up on top of a 1x2 image
+		8: image.Pt(0, 1),
+	}
+	for orientation, at := range want {
+		got := orient(img, orientation)
+		if r, _, _, _ := got.At(at.X, at.Y).RGBA(); r != 0xffff {
+			t.Errorf("orientation %d: red pixel not at %v", orientation, at)
+		}
+	}
+}
+
+func TestReadImageInfoWithoutExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil); err != nil {
+		t.Fatal(err)
+	}
+	info := ReadImageInfo(buf.Bytes())
+	if info.Orientation != 1 || info.CapturedAt != nil || info.Latitude != nil {
+		t.Errorf("ReadImageInfo without EXIF = %+v, want only orientation 1", info)
+	}
+}
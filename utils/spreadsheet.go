@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var ErrUnsupportedSpreadsheet = errors.New("file must be .csv or .xlsx")
+
+// ReadSpreadsheet returns the rows of a CSV file or of the first sheet of an XLSX file,
+// picked by the file extension. XLSX cells are read raw so numbers keep no formatting.
+func ReadSpreadsheet(fileName string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1 // trailing empty cells are often left out
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		// Spreadsheet programs put a BOM in front of UTF-8 CSV
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("workbook has no sheets")
+		}
+		return f.GetRows(sheets[0], excelize.Options{RawCellValue: true})
+	}
+	return nil, ErrUnsupportedSpreadsheet
+}
+
+// SpreadsheetWriter writes rows of a CSV file as they come, or collects the rows
+// of an XLSX file
+type SpreadsheetWriter interface {
+	WriteRow(values []interface{}) error
+	// Close finishes the file; for XLSX this is when the whole workbook is written out
+	Close() error
+}
+
+// MaxXLSXRows caps the data rows of an XLSX export, since the workbook is held in
+// memory until it is written out; larger exports go to CSV
+const MaxXLSXRows = 50000
+
+// SpreadsheetContentTypes maps the export formats to their content type
+var SpreadsheetContentTypes = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// NewSpreadsheetWriter starts a "csv" or "xlsx" file on w with the given header row
+func NewSpreadsheetWriter(format string, w io.Writer, headers []string) (SpreadsheetWriter, error) {
+	header := make([]interface{}, len(headers))
+	for i, h := range headers {
+		header[i] = h
+	}
+
+	var sw SpreadsheetWriter
+	switch format {
+	case "csv":
+		sw = &csvWriter{w: csv.NewWriter(w)}
+	case "xlsx":
+		f := excelize.NewFile()
+		stream, err := f.NewStreamWriter("Sheet1")
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		sw = &xlsxWriter{f: f, stream: stream, out: w, row: 1}
+	default:
+		return nil, ErrUnsupportedSpreadsheet
+	}
+	if err := sw.WriteRow(header); err != nil {
+		sw.Close()
+		return nil, err
+	}
+	return sw, nil
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = spreadsheetText(v)
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// xlsxWriter collects the rows through the excelize stream writer, which still holds the
+// workbook in memory until Close writes it out
+type xlsxWriter struct {
+	f      *excelize.File
+	stream *excelize.StreamWriter
+	out    io.Writer
+	row    int
+}
+
+func (x *xlsxWriter) WriteRow(values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case time.Time:
+			cells[i] = v.Format("2006-01-02 15:04:05")
+		case nil:
+			cells[i] = ""
+		default:
+			cells[i] = v
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	x.row++
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.f.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.f.Write(x.out)
+}
+
+// spreadsheetText renders a CSV cell. Text that a spreadsheet program would run as a formula
+// is prefixed with a quote; numbers are written as they are, so negative values stay numbers.
+func spreadsheetText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+			return "'" + v
+		}
+		return v
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
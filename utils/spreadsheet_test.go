@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpreadsheetText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{name: "nil", in: nil, want: ""},
+		{name: "plain text", in: "Rumah A", want: "Rumah A"},
+		{name: "empty text", in: "", want: ""},
+		{name: "formula", in: "=HYPERLINK(\"http://x\")", want: "'=HYPERLINK(\"http://x\")"},
+		{name: "plus", in: "+62 812", want: "'+62 812"},
+		{name: "minus", in: "-1+1", want: "'-1+1"},
+		{name: "at", in: "@SUM(A1)", want: "'@SUM(A1)"},
+		{name: "tab", in: "\t=1", want: "'\t=1"},
+		{name: "carriage return", in: "\r=1", want: "'\r=1"},
+		{name: "negative int", in: -5, want: "-5"},
+		{name: "negative float", in: -6.2088, want: "-6.2088"},
+		{name: "time", in: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC), want: "2024-05-01 08:30:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spreadsheetText(tt.in); got != tt.want {
+				t.Errorf("spreadsheetText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
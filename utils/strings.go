@@ -13,3 +13,11 @@ func SplitAndTrim(types string, s string) []string {
 	}
 	return result
 }
+
+// Truncate shortens s to at most n bytes, marking the cut with "..."
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}